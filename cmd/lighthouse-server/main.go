@@ -7,13 +7,48 @@ import (
 	"google.golang.org/grpc"
 	"log"
 	"net"
+	"strings"
+	"time"
 )
 
 var (
-	listenAddress = ":50051"
-	useDocker     = true
+	listenAddress  = ":50051"
+	useDocker      = true
+	runner         = ""
+	fakeResultsDir = "pkg/lighthouse/testdata"
+	fakeDelay      = time.Duration(0)
+	maxRunDuration = 120 * time.Second
+	maxConcurrent  = 1
+	maxQueued      = 10
+	policyFile     = ""
+	rawOptions     = false
+	denyConfigs    = false
+	configPlugins  = ""
+	allowedFlags   = ""
+	deniedFlags    = ""
+	allowedOptions = ""
+	deniedOptions  = ""
+	dockerImage    = pb.DefaultDockerImage
+	dockerFlows    = false
+	dockerCPUs     = ""
+	dockerMemory   = ""
+	dockerNetwork  = ""
+	workDir        = ""
+	lhVersion      = "9.4.0"
+	lhVersions     = ""
+	chromePoolSize = 0
+	chromeMaxRuns  = 50
+	chromePath     = "chromium-browser"
+	resultCacheTTL = time.Duration(0)
 )
 
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
 func main() {
 	flag.StringVar(&listenAddress, "listen-address",
 		cmd.GetenvString("LISTEN_ADDRESS", listenAddress),
@@ -21,15 +56,176 @@ func main() {
 	flag.BoolVar(&useDocker, "use-docker",
 		cmd.GetenvBool("USE_DOCKER", useDocker),
 		"Boolean to indicate whether docker should be used to run lighthouse. Default: true. Possible values: true, false.")
+	flag.StringVar(&runner, "runner",
+		cmd.GetenvString("RUNNER", runner),
+		"The runner used to execute lighthouse. Possible values: exec, docker, fake. Default: docker or exec depending on --use-docker.")
+	flag.StringVar(&fakeResultsDir, "fake-results-dir",
+		cmd.GetenvString("FAKE_RESULTS_DIR", fakeResultsDir),
+		"The directory with canned lighthouse JSON results replayed by the fake runner. Default: \"pkg/lighthouse/testdata\"")
+	flag.DurationVar(&fakeDelay, "fake-delay",
+		cmd.GetenvDuration("FAKE_DELAY", fakeDelay),
+		"The time the fake runner waits before returning a result to simulate a lighthouse run. Default: 0s")
+	flag.DurationVar(&maxRunDuration, "max-run-duration",
+		cmd.GetenvDuration("MAX_RUN_DURATION", maxRunDuration),
+		"The maximum duration of a single lighthouse run. Lighthouse and Chrome are killed when it's exceeded. Use 0 for no limit. Default: 120s")
+	flag.IntVar(&maxConcurrent, "max-concurrent-runs",
+		cmd.GetenvInt("MAX_CONCURRENT_RUNS", maxConcurrent),
+		"The maximum number of lighthouse runs executing at the same time. Use 0 for no limit. Default: 1")
+	flag.IntVar(&maxQueued, "max-queued-runs",
+		cmd.GetenvInt("MAX_QUEUED_RUNS", maxQueued),
+		"The maximum number of lighthouse runs waiting for a free slot. Runs are rejected with RESOURCE_EXHAUSTED when the queue is full. Default: 10")
+	flag.StringVar(&policyFile, "policy-file",
+		cmd.GetenvString("POLICY_FILE", policyFile),
+		`A JSON file with the policy for the chrome flags and lighthouse options clients can pass. This setting is optional.
+Example: {"allowed_chrome_flags": ["--disable-gpu"], "denied_option_prefixes": ["--locale"]}`)
+	flag.BoolVar(&rawOptions, "allow-raw-options",
+		cmd.GetenvBool("ALLOW_RAW_OPTIONS", rawOptions),
+		"Boolean flag to allow clients to pass lighthouse options instead of the typed fields of a request. The options are still restricted by the allowed and denied option prefixes. Default: false")
+	flag.BoolVar(&denyConfigs, "deny-lighthouse-configs",
+		cmd.GetenvBool("DENY_LIGHTHOUSE_CONFIGS", denyConfigs),
+		"Boolean flag to reject requests with a custom lighthouse config. Default: false")
+	flag.StringVar(&configPlugins, "allowed-config-plugins",
+		cmd.GetenvString("ALLOWED_CONFIG_PLUGINS", configPlugins),
+		"Comma separated list of lighthouse plugins custom lighthouse configs may use. Example: \"lighthouse-plugin-field-performance\"")
+	flag.StringVar(&allowedFlags, "allowed-chrome-flags",
+		cmd.GetenvString("ALLOWED_CHROME_FLAGS", allowedFlags),
+		"Comma separated list of chrome flags clients are allowed to pass. All flags that aren't denied are allowed when unset. Example: \"--disable-gpu,--lang\"")
+	flag.StringVar(&deniedFlags, "denied-chrome-flags",
+		cmd.GetenvString("DENIED_CHROME_FLAGS", deniedFlags),
+		"Comma separated list of chrome flags clients aren't allowed to pass in addition to the default denied flags like --user-data-dir.")
+	flag.StringVar(&allowedOptions, "allowed-option-prefixes",
+		cmd.GetenvString("ALLOWED_OPTION_PREFIXES", allowedOptions),
+		"Comma separated list of prefixes of lighthouse options clients are allowed to pass. All options that aren't denied are allowed when unset. Example: \"--throttling.,--form-factor\"")
+	flag.StringVar(&deniedOptions, "denied-option-prefixes",
+		cmd.GetenvString("DENIED_OPTION_PREFIXES", deniedOptions),
+		"Comma separated list of prefixes of lighthouse options clients aren't allowed to pass in addition to the default denied options like --config-path.")
+	flag.StringVar(&dockerImage, "docker-image",
+		cmd.GetenvString("DOCKER_IMAGE", dockerImage),
+		"The docker image used by the docker runner. Default: \""+pb.DefaultDockerImage+"\"")
+	flag.BoolVar(&dockerFlows, "docker-flows",
+		cmd.GetenvBool("DOCKER_FLOWS", dockerFlows),
+		"Boolean to indicate whether the docker images support user flows, like the images built from build/Dockerfile_lighthouse. The default image doesn't. Default: false")
+	flag.StringVar(&dockerCPUs, "docker-cpus",
+		cmd.GetenvString("DOCKER_CPUS", dockerCPUs),
+		"The number of CPUs available to each lighthouse container. This setting is optional. Example: \"1.5\"")
+	flag.StringVar(&dockerMemory, "docker-memory",
+		cmd.GetenvString("DOCKER_MEMORY", dockerMemory),
+		"The memory limit of each lighthouse container. This setting is optional. Example: \"2g\"")
+	flag.StringVar(&dockerNetwork, "docker-network",
+		cmd.GetenvString("DOCKER_NETWORK", dockerNetwork),
+		"The existing docker network that lighthouse containers are attached to. This setting is optional. Example: \"lighthouse\"")
+	flag.StringVar(&workDir, "work-dir",
+		cmd.GetenvString("WORK_DIR", workDir),
+		"The directory where lighthouse writes HTML reports and traces before they're returned. When the docker runner talks to the docker daemon of the host, the directory must exist at the same path on the host. Default: the system temp directory")
+	flag.StringVar(&lhVersion, "lighthouse-version",
+		cmd.GetenvString("LIGHTHOUSE_VERSION", lhVersion),
+		"The version of the default lighthouse installation or docker image. Default: \"9.4.0\"")
+	flag.StringVar(&lhVersions, "lighthouse-versions",
+		cmd.GetenvString("LIGHTHOUSE_VERSIONS", lhVersions),
+		`Comma separated list of additional lighthouse versions clients can request. Each version maps to the docker image
+for the docker runner or to the lighthouse executable for the exec runner. This setting is optional.
+Example: "9.6.0=samos123/lighthouse:9.6.0,10.0.0=samos123/lighthouse:10.0.0"`)
+	flag.IntVar(&chromePoolSize, "chrome-pool-size",
+		cmd.GetenvInt("CHROME_POOL_SIZE", chromePoolSize),
+		"The number of headless Chrome instances kept running that lighthouse connects to instead of starting Chrome for every run. Only supported by the exec runner. Use 0 to disable the pool. Default: 0")
+	flag.IntVar(&chromeMaxRuns, "chrome-pool-max-runs",
+		cmd.GetenvInt("CHROME_POOL_MAX_RUNS", chromeMaxRuns),
+		"The number of runs after which a pooled Chrome instance is replaced by a fresh one. Use 0 to only replace crashed instances. Default: 50")
+	flag.StringVar(&chromePath, "chrome-path",
+		cmd.GetenvString("CHROME_PATH", chromePath),
+		"The Chrome executable started by the Chrome pool. Default: \"chromium-browser\"")
+	flag.DurationVar(&resultCacheTTL, "result-cache-ttl",
+		cmd.GetenvDuration("RESULT_CACHE_TTL", resultCacheTTL),
+		"How long the result of a run is returned for identical requests instead of running lighthouse again. Use 0 to disable the cache. Default: 0s")
 	flag.Parse()
 
+	if runner == "" {
+		if useDocker {
+			runner = "docker"
+		} else {
+			runner = "exec"
+		}
+	}
+	config := pb.RunnerConfig{
+		FakeResultsDir: fakeResultsDir,
+		FakeDelay:      fakeDelay,
+		DockerImage:    dockerImage,
+		DockerFlows:    dockerFlows,
+		DockerCPUs:     dockerCPUs,
+		DockerMemory:   dockerMemory,
+		DockerNetwork:  dockerNetwork,
+	}
+	r, err := pb.NewRunner(runner, config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	versions := map[string]pb.Runner{}
+	for _, v := range splitList(lhVersions) {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			log.Fatalf("Invalid lighthouse version %q, expected <version>=<docker image or executable>", v)
+		}
+		versionConfig := config
+		versionConfig.DockerImage = parts[1]
+		versionConfig.LighthousePath = parts[1]
+		if versions[parts[0]], err = pb.NewRunner(runner, versionConfig); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	lis, err := net.Listen("tcp", listenAddress)
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
+	policy := pb.DefaultPolicy()
+	if policyFile != "" {
+		if policy, err = pb.LoadPolicy(policyFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	policy.Merge(&pb.Policy{
+		AllowRawOptions:       rawOptions,
+		DenyConfigs:           denyConfigs,
+		AllowedConfigPlugins:  splitList(configPlugins),
+		AllowedChromeFlags:    splitList(allowedFlags),
+		DeniedChromeFlags:     splitList(deniedFlags),
+		AllowedOptionPrefixes: splitList(allowedOptions),
+		DeniedOptionPrefixes:  splitList(deniedOptions),
+	})
+
+	server := &pb.Server{
+		Runner:            r,
+		LighthouseVersion: lhVersion,
+		Versions:          versions,
+		MaxRunDuration:    maxRunDuration,
+		Policy:            policy,
+		WorkDir:           workDir,
+	}
+	if maxConcurrent > 0 {
+		server.Queue = pb.NewRunQueue(maxConcurrent, maxQueued)
+	}
+	if resultCacheTTL > 0 {
+		server.Cache = pb.NewResultCache(resultCacheTTL)
+	}
+	if chromePoolSize > 0 {
+		// Lighthouse in a container can't reach Chrome on the host
+		if runner != "exec" {
+			log.Fatalf("The Chrome pool is only supported by the exec runner, not by the %s runner", runner)
+		}
+		pool, err := pb.NewChromePool(pb.ChromePoolConfig{
+			Size:       chromePoolSize,
+			MaxRuns:    chromeMaxRuns,
+			ChromePath: chromePath,
+		})
+		if err != nil {
+			log.Fatalf("Error starting the Chrome pool: %v", err)
+		}
+		defer pool.Close()
+		server.ChromePool = pool
+	}
 	s := grpc.NewServer()
-	pb.RegisterLighthouseServiceServer(s, &pb.Server{UseDocker: useDocker})
-	log.Printf("listening on %v", listenAddress)
+	pb.RegisterLighthouseServiceServer(s, server)
+	log.Printf("listening on %v using the %s runner", listenAddress, runner)
 	if err := s.Serve(lis); err != nil {
 		log.Fatalf("failed to serve: %v", err)
 	}
@@ -29,6 +29,18 @@ var (
 	smtpUsername           = ""
 	smtpPassword           = ""
 	fromEmail              = "info@websu.io"
+	encryptionKey          = ""
+	maxLoadPerCPU          = api.UnreliableMaxLoadPerCPU
+	maxConcurrentRuns      = api.UnreliableMaxConcurrentRuns
+	minBenchmarkIndex      = api.UnreliableMinBenchmarkIndex
+	reportCacheTTL         = api.ReportCacheTTL
+	retryMaxAttempts       = api.RetryMaxAttempts
+	retryBackoff           = api.RetryBackoff
+	retryOtherLocation     = api.RetryOtherLocation
+	jobWorkers             = api.JobWorkers
+	jobQueueSize           = api.JobQueueSize
+	jobRetention           = api.JobRetention
+	jobTimeout             = api.JobTimeout
 )
 
 // @title Websu API
@@ -89,6 +101,39 @@ local memory will be used instead of Redis. Example redis://localhost:6379/0`)
 		"SMTP password used for sending email. This setting is optional.")
 	flag.StringVar(&fromEmail, "from-email", cmd.GetenvString("FROM_EMAIL", fromEmail),
 		"The email address of sender when sending email. This setting is optional.")
+	flag.StringVar(&encryptionKey, "encryption-key", cmd.GetenvString("ENCRYPTION_KEY", encryptionKey),
+		`Base64 encoded 32 byte key used to encrypt the extra headers and cookies of scheduled reports.
+This setting is optional, but scheduled reports can't use headers and cookies without it. Generate one with: openssl rand -base64 32`)
+	flag.Float64Var(&maxLoadPerCPU, "unreliable-max-load-per-cpu",
+		cmd.GetenvFloat("UNRELIABLE_MAX_LOAD_PER_CPU", maxLoadPerCPU),
+		"Reports are flagged as unreliable when the load average per CPU of the lighthouse-server host exceeds this value. 0 disables the check. Default: 1")
+	flag.IntVar(&maxConcurrentRuns, "unreliable-max-concurrent-runs",
+		cmd.GetenvInt("UNRELIABLE_MAX_CONCURRENT_RUNS", maxConcurrentRuns),
+		"Reports are flagged as unreliable when more runs executed on the lighthouse-server at the same time. 0 disables the check. Default: 0")
+	flag.Float64Var(&minBenchmarkIndex, "unreliable-min-benchmark-index",
+		cmd.GetenvFloat("UNRELIABLE_MIN_BENCHMARK_INDEX", minBenchmarkIndex),
+		"Reports are flagged as unreliable when the lighthouse benchmarkIndex of the host is lower. 0 disables the check. Default: 0")
+	flag.DurationVar(&reportCacheTTL, "report-cache-ttl",
+		cmd.GetenvDuration("REPORT_CACHE_TTL", reportCacheTTL),
+		`How long the report of a request is returned for identical requests instead of running lighthouse again.
+The cache uses Redis when --redis-url is set and local memory otherwise. Use 0 to disable the cache. Default: 0s`)
+	flag.IntVar(&retryMaxAttempts, "retry-max-attempts",
+		cmd.GetenvInt("RETRY_MAX_ATTEMPTS", retryMaxAttempts),
+		"The maximum number of attempts of a lighthouse run that fails with a transient error like a Chrome crash, a protocol timeout or an unavailable lighthouse-server. Use 1 to disable retries. Default: 3")
+	flag.DurationVar(&retryBackoff, "retry-backoff",
+		cmd.GetenvDuration("RETRY_BACKOFF", retryBackoff),
+		"The delay before the first retry of a lighthouse run, which doubles with every further retry. Default: 2s")
+	flag.BoolVar(&retryOtherLocation, "retry-other-location",
+		cmd.GetenvBool("RETRY_OTHER_LOCATION", retryOtherLocation),
+		"Boolean flag to indicate whether retries should run on another location than the attempt that failed. Default: false")
+	flag.IntVar(&jobWorkers, "job-workers", cmd.GetenvInt("JOB_WORKERS", jobWorkers),
+		"The number of reports requested with async=true that are created at the same time. Default: 2")
+	flag.IntVar(&jobQueueSize, "job-queue-size", cmd.GetenvInt("JOB_QUEUE_SIZE", jobQueueSize),
+		"The number of async reports that can wait for a job worker. Further async requests are rejected with 503. Default: 100")
+	flag.DurationVar(&jobRetention, "job-retention", cmd.GetenvDuration("JOB_RETENTION", jobRetention),
+		"How long async jobs are kept in mongo after they were created. Default: 168h")
+	flag.DurationVar(&jobTimeout, "job-timeout", cmd.GetenvDuration("JOB_TIMEOUT", jobTimeout),
+		"How long async jobs may wait for a worker and then run before they are failed, e.g. because websu-api was restarted. 0 disables the timeout. Default: 1h")
 	flag.Parse()
 
 	docs.SwaggerInfo.Host = apiHost
@@ -105,6 +150,19 @@ local memory will be used instead of Redis. Example redis://localhost:6379/0`)
 	if auth == "firebase" {
 		api.InitFirebase()
 	}
+	if encryptionKey != "" {
+		if err := api.SetEncryptionKey(encryptionKey); err != nil {
+			log.Fatal(err)
+		}
+	}
+	api.ReportCacheTTL = reportCacheTTL
+	api.RetryMaxAttempts = retryMaxAttempts
+	api.RetryBackoff = retryBackoff
+	api.RetryOtherLocation = retryOtherLocation
+	api.JobWorkers = jobWorkers
+	api.JobQueueSize = jobQueueSize
+	api.JobRetention = jobRetention
+	api.JobTimeout = jobTimeout
 	a := api.NewApp(options...)
 	api.LighthouseClient = api.ConnectToLighthouseServer(lighthouseServer, lighthouseServerSecure)
 	api.CreateMongoClient(mongoURI)
@@ -128,6 +186,9 @@ local memory will be used instead of Redis. Example redis://localhost:6379/0`)
 	api.SmtpUsername = smtpUsername
 	api.SmtpPassword = smtpPassword
 	api.FromEmail = fromEmail
+	api.UnreliableMaxLoadPerCPU = maxLoadPerCPU
+	api.UnreliableMaxConcurrentRuns = maxConcurrentRuns
+	api.UnreliableMinBenchmarkIndex = minBenchmarkIndex
 
 	a.Run(listenAddress)
 }
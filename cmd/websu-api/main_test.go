@@ -48,7 +48,7 @@ func checkResponseCode(t *testing.T, expected int, response *httptest.ResponseRe
 
 func deleteAllReports() {
 
-	reports, err := api.GetReports(500, 0, nil)
+	reports, err := api.GetReports(500, 0, nil, "")
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -60,6 +60,18 @@ func deleteAllReports() {
 	api.DatabaseName = "websu-test"
 }
 
+func expectRunStream(ctrl *gomock.Controller, client *mocks.MockLighthouseServiceClient, stdout []byte) {
+	stream := mocks.NewMockLighthouseService_RunStreamClient(ctrl)
+	stream.EXPECT().Recv().Return(&lighthouse.RunStreamResponse{
+		Event: &lighthouse.RunStreamResponse_Chunk{Chunk: &lighthouse.ResultChunk{
+			Data:      stdout,
+			TotalSize: int64(len(stdout)),
+			Last:      true,
+		}},
+	}, nil)
+	client.EXPECT().RunStream(gomock.Any(), gomock.Any()).Return(stream, nil)
+}
+
 func createReport(t *testing.T, body []byte, mockLighthouseServer bool) *httptest.ResponseRecorder {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -68,9 +80,7 @@ func createReport(t *testing.T, body []byte, mockLighthouseServer bool) *httptes
 	report := bytes.NewBuffer(body)
 	req, _ := http.NewRequest("POST", "/reports", report)
 	if mockLighthouseServer {
-		mockLightHouseClient.EXPECT().Run(gomock.Any(), gomock.Any()).Return(
-			&lighthouse.LighthouseResult{Stdout: []byte("{}")}, nil,
-		)
+		expectRunStream(ctrl, mockLightHouseClient, []byte("{}"))
 	}
 	resp := executeRequest(req)
 	return resp
@@ -172,9 +182,7 @@ func TestCreateReportNoFullResult(t *testing.T) {
 	api.LighthouseClient = mockLightHouseClient
 	report := bytes.NewBuffer(body)
 	req, _ := http.NewRequest("POST", "/reports?fullResult=false", report)
-	mockLightHouseClient.EXPECT().Run(gomock.Any(), gomock.Any()).Return(
-		&lighthouse.LighthouseResult{Stdout: []byte("{'test': 'true'}")}, nil,
-	)
+	expectRunStream(ctrl, mockLightHouseClient, []byte("{'test': 'true'}"))
 	response := executeRequest(req)
 	checkResponseCode(t, http.StatusOK, response)
 	var responseReport api.Report
@@ -195,9 +203,7 @@ func TestCreateReportFullResult(t *testing.T) {
 	api.LighthouseClient = mockLightHouseClient
 	report := bytes.NewBuffer(body)
 	req, _ := http.NewRequest("POST", "/reports?fullResult=true", report)
-	mockLightHouseClient.EXPECT().Run(gomock.Any(), gomock.Any()).Return(
-		&lighthouse.LighthouseResult{Stdout: []byte("{'test': 'true'}")}, nil,
-	)
+	expectRunStream(ctrl, mockLightHouseClient, []byte("{'test': 'true'}"))
 	response := executeRequest(req)
 	checkResponseCode(t, http.StatusOK, response)
 	var responseReport api.Report
@@ -210,6 +216,37 @@ func TestCreateReportFullResult(t *testing.T) {
 	deleteAllReports()
 }
 
+func TestCreateReportMultipleRuns(t *testing.T) {
+	body := []byte(`{"URL": "https://www.google.com", "runs": 3}`)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockLightHouseClient := mocks.NewMockLighthouseServiceClient(ctrl)
+	api.LighthouseClient = mockLightHouseClient
+	for _, fcp := range []int{1000, 3000, 1500} {
+		lhr := fmt.Sprintf(`{"audits": {"first-contentful-paint": {"numericValue": %d}, "interactive": {"numericValue": %d}}}`,
+			fcp, 2*fcp)
+		expectRunStream(ctrl, mockLightHouseClient, []byte(lhr))
+	}
+	req, _ := http.NewRequest("POST", "/reports", bytes.NewBuffer(body))
+	response := executeRequest(req)
+	checkResponseCode(t, http.StatusOK, response)
+	var responseReport api.Report
+	if err := json.NewDecoder(response.Body).Decode(&responseReport); err != nil {
+		t.Fatal(err)
+	}
+	if len(responseReport.RunResults) != 3 || responseReport.MedianRun != 2 {
+		t.Errorf("Expected 3 runs with median run 2, but got %d runs with median run %d",
+			len(responseReport.RunResults), responseReport.MedianRun)
+	}
+	if fcp := responseReport.AuditResults["first-contentful-paint"].NumericValue; fcp != 1500 {
+		t.Errorf("Expected the audit results of the median run, but got first-contentful-paint %v", fcp)
+	}
+	if stats := responseReport.RunStats["first-contentful-paint"]; stats.Min != 1000 || stats.Max != 3000 {
+		t.Errorf("Unexpected first-contentful-paint stats %+v", stats)
+	}
+	deleteAllReports()
+}
+
 func TestCreateReportRateLimit(t *testing.T) {
 	body := []byte(`{"URL": "https://www.google.com"}`)
 	var mock bool
@@ -340,6 +377,27 @@ func TestDeleteReportNonExisting(t *testing.T) {
 
 }
 
+func TestGetLighthouseVersions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockLightHouseClient := mocks.NewMockLighthouseServiceClient(ctrl)
+	api.LighthouseClient = mockLightHouseClient
+	mockLightHouseClient.EXPECT().Status(gomock.Any(), gomock.Any()).Return(&lighthouse.StatusResponse{
+		LighthouseVersions:       []string{"9.4.0", "9.6.0"},
+		DefaultLighthouseVersion: "9.4.0",
+	}, nil)
+	req, _ := http.NewRequest("GET", "/lighthouse-versions", nil)
+	response := executeRequest(req)
+	checkResponseCode(t, http.StatusOK, response)
+	var versions api.LighthouseVersions
+	if err := json.NewDecoder(response.Body).Decode(&versions); err != nil {
+		t.Fatal(err)
+	}
+	if len(versions.Versions) != 2 || versions.Default != "9.4.0" {
+		t.Errorf("Unexpected lighthouse versions %+v", versions)
+	}
+}
+
 func TestGetLocationsEmpty(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/locations", nil)
 	response := executeRequest(req)
@@ -544,13 +602,17 @@ func TestHTTPRunReport(t *testing.T) {
 	ts := httptest.NewServer(a.Router)
 	defer ts.Close()
 	api.ApiUrl = ts.URL
-	rr := api.ReportRequest{URL: "https://www.google.com"}
+	sr := api.NewScheduledReport()
+	sr.URL = "https://www.google.com"
+	sr.Schedule = "daily"
+	if err := sr.Insert(); err != nil {
+		t.Fatal(err)
+	}
+	defer sr.Delete()
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	mockLightHouseClient := mocks.NewMockLighthouseServiceClient(ctrl)
 	api.LighthouseClient = mockLightHouseClient
-	mockLightHouseClient.EXPECT().Run(gomock.Any(), gomock.Any()).Return(
-		&lighthouse.LighthouseResult{Stdout: []byte("{}")}, nil,
-	)
-	api.HTTPRunReport(rr)
+	expectRunStream(ctrl, mockLightHouseClient, []byte("{}"))
+	api.HTTPRunReport(*sr)
 }
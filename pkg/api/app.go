@@ -7,10 +7,11 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
-	"runtime/debug"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	libredis "github.com/go-redis/redis/v8"
@@ -21,8 +22,12 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 	mhttp "github.com/ulule/limiter/v3/drivers/middleware/stdlib"
 	pb "github.com/websu-io/websu/pkg/lighthouse"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
 )
 
 var (
@@ -41,6 +46,13 @@ var (
 type App struct {
 	Router      *mux.Router
 	RedisClient *libredis.Client
+	// reportCache is nil when ReportCacheTTL is zero
+	reportCache reportCache
+	// jobs holds the async jobs that wait for a worker
+	jobs chan queuedJob
+	// jobEvents holds the progress events of the jobs run by this instance
+	jobEvents   map[primitive.ObjectID]*jobEventLog
+	jobEventsMu sync.Mutex
 }
 
 func ConnectToLighthouseServer(address string, secure bool) pb.LighthouseServiceClient {
@@ -90,6 +102,10 @@ func NewApp(opts ...AppOption) *App {
 	for _, opt := range opts {
 		opt(a)
 	}
+	if ReportCacheTTL > 0 {
+		a.reportCache = newReportCache(a.RedisClient)
+	}
+	a.startJobWorkers()
 	a.SetupRoutes()
 	LighthouseClients = make(map[string]pb.LighthouseServiceClient)
 	return a
@@ -108,17 +124,30 @@ func (a *App) SetupRoutes() {
 	a.Router.HandleFunc("/reports", a.getReports).Methods("GET")
 	a.Router.HandleFunc("/reports/count", a.getReportsCount).Methods("GET")
 	a.Router.Handle("/reports", limiter.Handler(http.HandlerFunc(a.createReport))).Methods("POST")
+	a.Router.Handle("/experiments", limiter.Handler(http.HandlerFunc(a.createExperiment))).Methods("POST")
 	a.Router.HandleFunc("/reports/{id}", a.getReport).Methods("GET")
+	a.Router.HandleFunc("/reports/{id}/html", a.getReportHTML).Methods("GET")
+	a.Router.HandleFunc("/reports/{id}/screenshots", a.getReportScreenshots).Methods("GET")
+	a.Router.HandleFunc("/reports/{id}/trace", a.getReportTrace).Methods("GET")
+	a.Router.HandleFunc("/reports/{id}/debug", a.getReportDebug).Methods("GET")
+	a.Router.HandleFunc("/jobs/{id}", a.getJob).Methods("GET")
+	a.Router.HandleFunc("/jobs/{id}/events", a.getJobEvents).Methods("GET")
 	a.Router.HandleFunc("/scheduled-reports", a.ScheduledReportsGet).Methods("GET")
 	a.Router.Handle("/scheduled-reports", limiter.Handler(http.HandlerFunc(a.ScheduledReportsPost))).Methods("POST")
 	a.Router.HandleFunc("/scheduled-reports/run", a.RunScheduledReports).Methods("GET")
 	a.Router.HandleFunc("/scheduled-reports/{id}", a.ScheduledReportGet).Methods("GET")
+	a.Router.Handle("/scheduled-reports/{id}/run", limiter.Handler(http.HandlerFunc(a.runScheduledReport))).Methods("POST")
 	a.Router.PathPrefix("/docs/").Handler(httpSwagger.WrapHandler)
 	a.Router.HandleFunc("/locations", a.getLocations).Methods("GET")
+	a.Router.HandleFunc("/lighthouse-versions", a.getLighthouseVersions).Methods("GET")
+	a.Router.HandleFunc("/lighthouse-configs", a.getLighthouseConfigs).Methods("GET")
+	a.Router.HandleFunc("/lighthouse-configs/{name}", a.getLighthouseConfig).Methods("GET")
 	if EnableAdminAPIs == true {
 		a.Router.HandleFunc("/locations", a.createLocation).Methods("POST")
 		a.Router.HandleFunc("/locations/{id}", a.updateLocation).Methods("PUT")
 		a.Router.HandleFunc("/locations/{id}", a.deleteLocation).Methods("DELETE")
+		a.Router.HandleFunc("/lighthouse-configs/{name}", a.putLighthouseConfig).Methods("PUT")
+		a.Router.HandleFunc("/lighthouse-configs/{name}", a.deleteLighthouseConfig).Methods("DELETE")
 		a.Router.HandleFunc("/reports/{id}", a.deleteReport).Methods("DELETE")
 		a.Router.HandleFunc("/scheduled-reports/{id}", a.ScheduledReportDelete).Methods("DELETE")
 	}
@@ -178,14 +207,16 @@ func (a *App) getReports(w http.ResponseWriter, r *http.Request) {
 	if limit == 0 {
 		limit = 50
 	}
-	var reports []Report
+	query, sort, err := reportsQuery(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if user := r.Context().Value("UserID"); user != nil {
 		log.WithField("user", user.(string)).Info("Getting reports for user")
-		query := map[string]interface{}{"user": user.(string)}
-		reports, err = GetReports(limit, skip, query)
-	} else {
-		reports, err = GetReports(limit, skip, nil)
+		query["user"] = user.(string)
 	}
+	reports, err := GetReports(limit, skip, query, sort)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -193,6 +224,70 @@ func (a *App) getReports(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(&reports)
 }
 
+// reportsQuery returns the Mongo query and sort order of the filter and sort
+// parameters of the reports listing. Category scores are filtered with
+// min_score.<category> and max_score.<category> and sorted with
+// sort=<category>, or sort=-<category> for descending order. Reports are
+// filtered by their unreliable flag with unreliable=true or unreliable=false.
+func reportsQuery(q url.Values) (map[string]interface{}, string, error) {
+	query := map[string]interface{}{}
+	for _, bound := range []struct {
+		param    string
+		operator string
+	}{{"min_score.", "$gte"}, {"max_score.", "$lte"}} {
+		for _, category := range pb.Categories {
+			v := q.Get(bound.param + category)
+			if v == "" {
+				continue
+			}
+			score, err := strconv.ParseFloat(v, 64)
+			if err != nil || score < 0 || score > 1 {
+				return nil, "", fmt.Errorf("%s%s must be a score between 0 and 1", bound.param, category)
+			}
+			field := "scores." + category
+			filter, _ := query[field].(map[string]interface{})
+			if filter == nil {
+				filter = map[string]interface{}{}
+				query[field] = filter
+			}
+			filter[bound.operator] = score
+		}
+	}
+	if v := q.Get("status"); v != "" {
+		if !pb.Contains(reportStatuses, v) {
+			return nil, "", fmt.Errorf("status must be one of %s", strings.Join(reportStatuses, ", "))
+		}
+		if v == ReportStatusSucceeded {
+			// Reports created before the status existed succeeded
+			query["status"] = map[string]interface{}{"$in": []interface{}{v, nil}}
+		} else {
+			query["status"] = v
+		}
+	}
+	if v := q.Get("unreliable"); v != "" {
+		unreliable, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, "", fmt.Errorf("unreliable must be true or false")
+		}
+		if unreliable {
+			query["unreliable"] = true
+		} else {
+			// Reports created before the flag existed don't have it
+			query["unreliable"] = map[string]interface{}{"$ne": true}
+		}
+	}
+	sort := q.Get("sort")
+	switch field := strings.TrimPrefix(sort, "-"); {
+	case field == "" || field == "created_at":
+	case pb.Contains(pb.Categories, field):
+		sort = strings.Replace(sort, field, "scores."+field, 1)
+	default:
+		return nil, "", fmt.Errorf("Can't sort by %q. Possible values are created_at and the categories %s",
+			field, strings.Join(pb.Categories, ", "))
+	}
+	return query, sort, nil
+}
+
 func (a *App) getReportsCount(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	count, err := GetAllReportsCount()
@@ -206,11 +301,15 @@ func (a *App) getReportsCount(w http.ResponseWriter, r *http.Request) {
 
 // @Summary Create a Lighthouse Report
 // @Description Run a lighthouse audit to generate a report. The field `raw_json` contains the
-// @Description JSON output returned from lighthouse as a string.
+// @Description JSON output returned from lighthouse as a string. Failed reports are returned
+// @Description with status 500.
 // @Accept  json
 // @Param ReportRequest body api.ReportRequest true "Lighthouse parameters to generate the report"
+// @Param cache query bool false "Set to false to run lighthouse even if the report of an identical request is cached"
+// @Param async query bool false "Set to true to create the report in the background. Returns a job that can be polled at the URL in the Location header"
 // @Produce  json
 // @Success 200 {array} api.Report
+// @Success 202 {object} api.Job
 // @Router /reports [post]
 func (a *App) createReport(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -222,90 +321,366 @@ func (a *App) createReport(w http.ResponseWriter, r *http.Request) {
 			fullResult = b
 		}
 	}
+	useCache := true
+	if b, err := strconv.ParseBool(query.Get("cache")); err == nil {
+		useCache = b
+	}
+	async, _ := strconv.ParseBool(query.Get("async"))
+	reportRequest, ok := decodeReportRequest(w, r)
+	if !ok {
+		return
+	}
+	if async {
+		job, err := a.enqueueJob(reportRequest, userID(r), useCache)
+		if err == errJobQueueFull {
+			w.Header().Set("Retry-After", "30")
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		} else if err != nil {
+			log.WithError(err).Error("Error creating job")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Location", "/jobs/"+job.ID.Hex())
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(job)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), reportTimeout(reportRequest))
+	defer cancel()
+	report, err := a.newReport(ctx, reportRequest, userID(r), useCache)
+	writeNewReport(w, report, err, fullResult)
+}
+
+// writeNewReport writes the response of a report that was just created.
+func writeNewReport(w http.ResponseWriter, report *Report, err error, fullResult bool) {
+	if err != nil && report == nil {
+		writeLighthouseError(w, err)
+		return
+	}
+	if !fullResult {
+		report.RawJSON = ""
+	}
+	if err != nil {
+		// The failed report was stored and explains what went wrong
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	json.NewEncoder(w).Encode(&report)
+}
+
+// newReport returns the cached report of an identical request when useCache
+// is set or runs lighthouse, and emails the report. Failed reports are
+// emailed and returned together with an error, whether lighthouse reported a
+// runtime error or lighthouse-server returned an error.
+func (a *App) newReport(ctx context.Context, rr *ReportRequest, user string, useCache bool) (*Report, error) {
+	var report *Report
+	if useCache {
+		report = a.cachedReport(rr, user)
+	}
+	var err error
+	if report == nil {
+		if report, err = runReport(ctx, rr, user, useCache); report == nil {
+			return nil, err
+		}
+		if report.Status == ReportStatusFailed {
+			if err == nil {
+				err = fmt.Errorf("Lighthouse failed with %v", report.RuntimeError)
+			}
+		} else {
+			a.cacheReport(rr, user, report)
+		}
+	}
+	if err := report.SendEmail(); err != nil {
+		log.WithError(err).WithField("report", report.ID).Error("Error sending email")
+	}
+	return report, err
+}
+
+// cachedReport returns the report of an identical request of user that was
+// created within ReportCacheTTL or nil when there is none.
+func (a *App) cachedReport(rr *ReportRequest, user string) *Report {
+	if a.reportCache == nil {
+		return nil
+	}
+	id, ok, err := a.reportCache.Get(context.Background(), reportCacheKey(rr, user))
+	if err != nil {
+		log.WithError(err).Warn("Error getting report from cache")
+		return nil
+	} else if !ok {
+		return nil
+	}
+	report, err := GetReportByObjectIDHex(id)
+	if err != nil {
+		log.WithError(err).WithField("report", id).Warn("Error getting cached report")
+		return nil
+	}
+	log.WithField("report", id).Info("Returning cached report")
+	report.Cached = true
+	report.Email = rr.Email
+	return &report
+}
+
+// cacheReport stores the ID of report for requests of user identical to rr.
+func (a *App) cacheReport(rr *ReportRequest, user string, report *Report) {
+	if a.reportCache == nil {
+		return
+	}
+	if err := a.reportCache.Set(context.Background(), reportCacheKey(rr, user), report.ID.Hex(), ReportCacheTTL); err != nil {
+		log.WithError(err).WithField("report", report.ID).Warn("Error caching report")
+	}
+}
+
+// @Summary Run a request blocking experiment
+// @Description Runs a baseline report and a report that blocks the requests matching
+// @Description blocked_url_patterns back-to-back on the same location. Both reports are
+// @Description stored and returned together with the metric deltas between them. The
+// @Description response has status 500 and no deltas when one of the reports failed.
+// @Accept  json
+// @Param ReportRequest body api.ReportRequest true "Lighthouse parameters including blocked_url_patterns"
+// @Produce  json
+// @Success 200 {object} api.Experiment
+// @Router /experiments [post]
+func (a *App) createExperiment(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	blockedRequest, ok := decodeReportRequest(w, r)
+	if !ok {
+		return
+	}
+	if len(blockedRequest.BlockedURLPatterns) == 0 {
+		http.Error(w, "blocked_url_patterns: cannot be blank.", http.StatusBadRequest)
+		return
+	}
+	baselineRequest := *blockedRequest
+	baselineRequest.BlockedURLPatterns = nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*reportTimeout(blockedRequest))
+	defer cancel()
+	// Both reports must run back-to-back on the same location to be
+	// comparable, so cached results aren't used
+	ctx = withSameLocation(ctx)
+	baseline, err := runReport(ctx, &baselineRequest, userID(r), false)
+	if err != nil && baseline == nil {
+		writeLighthouseError(w, err)
+		return
+	}
+	baseline.RawJSON = ""
+	var blocked *Report
+	var blockedErr error
+	if baseline.Status != ReportStatusFailed {
+		if blocked, blockedErr = runReport(ctx, blockedRequest, userID(r), false); blocked != nil {
+			blocked.RawJSON = ""
+		}
+	}
+	experiment := newExperiment(blockedRequest.BlockedURLPatterns, baseline, blocked)
+	if blocked == nil && blockedErr != nil {
+		experiment.Error = "Error creating the blocked report: " + blockedErr.Error()
+	}
+	if experiment.Error != "" {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	json.NewEncoder(w).Encode(experiment)
+}
+
+// decodeReportRequest decodes and validates the ReportRequest in the body of
+// r. It writes an error response and returns false when that fails.
+func decodeReportRequest(w http.ResponseWriter, r *http.Request) (*ReportRequest, bool) {
 	var reportRequest ReportRequest
 	if err := decodeJSONBody(w, r, &reportRequest); err != nil {
 		var mr *malformedRequest
 		if errors.As(err, &mr) {
-			log.WithError(err).Error("Malformed Request during decoding JSON of createReport")
+			log.WithError(err).Error("Malformed Request during decoding JSON of ReportRequest")
 			http.Error(w, mr.msg, mr.status)
 		} else {
 			log.WithError(err).Error("Error decoding JSON")
 			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		}
-		return
+		return nil, false
 	}
-	log.Infof("Decoded json from HTTP body. ReportRequest: %+v", reportRequest)
+	log.Infof("Decoded json from HTTP body. ReportRequest: %+v", reportRequest.redacted())
+	// Encrypted credentials are only decrypted from the scheduled reports
+	// stored in mongo, so clients can't replay another user's credentials.
+	reportRequest.EncryptedCredentials = ""
 	if err := reportRequest.Validate(); err != nil {
-		log.WithError(err).WithField("reportRequest", reportRequest).Info("Unable to validate ReportRequest")
+		log.WithError(err).WithField("reportRequest", reportRequest.redacted()).Info("Unable to validate ReportRequest")
 		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+		return nil, false
 	}
 	if reportRequest.FormFactor == "" {
 		reportRequest.FormFactor = "desktop"
 	}
-	if reportRequest.ThroughputKbps < 1000 {
-		reportRequest.ThroughputKbps = 1000
+	if err := reportRequest.resolveThrottling(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
 	}
-	lhOptions := []string{
-		fmt.Sprintf("--emulated-form-factor=%v", reportRequest.FormFactor),
-		fmt.Sprintf("--throttling.throughputKbps=%v", reportRequest.ThroughputKbps),
-		"--throttling.rttMs=0",
-		"--throttling.cpuSlowdownMultiplier=1",
-		"--throttling.requestLatencyMs=0",
-		"--throttling.downloadThroughputKbps=0",
-		"--throttling.uploadThroughputKbps=0",
+	return &reportRequest, true
+}
+
+// userID returns the ID of the authenticated user or an empty string.
+func userID(r *http.Request) string {
+	if user := r.Context().Value("UserID"); user != nil {
+		return user.(string)
 	}
+	return ""
+}
 
-	lhRequest := pb.LighthouseRequest{
-		Url:     reportRequest.URL,
-		Options: lhOptions,
+// writeLighthouseError writes the error response for a report that couldn't
+// be created.
+func writeLighthouseError(w http.ResponseWriter, err error) {
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case codes.ResourceExhausted:
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(st)))
+			http.Error(w, st.Message(), http.StatusServiceUnavailable)
+			return
+		case codes.InvalidArgument:
+			http.Error(w, st.Message(), http.StatusBadRequest)
+			return
+		}
 	}
-	var lhClient pb.LighthouseServiceClient
-	if val, ok := LighthouseClients[reportRequest.Location]; ok {
-		lhClient = val
-	} else {
-		lhClient = LighthouseClient
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+func (a *App) getReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	params := mux.Vars(r)
+	report, err := GetReportByObjectIDHex(params["id"])
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents in result") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
 	}
+	json.NewEncoder(w).Encode(&report)
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*120)
-	defer cancel()
-	lhResult, err := lhClient.Run(ctx, &lhRequest)
+// getPathReport returns the report with the id in the path of r. It writes an
+// error response and returns false when the report doesn't exist.
+func getPathReport(w http.ResponseWriter, r *http.Request) (Report, bool) {
+	report, err := GetReportByObjectIDHex(mux.Vars(r)["id"])
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents in result") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return report, false
+	}
+	return report, true
+}
+
+// getReportArtifacts writes an error response and returns false when the
+// report doesn't exist or has no artifacts of the given types.
+func getReportArtifacts(w http.ResponseWriter, r *http.Request, types ...pb.ArtifactType) ([]ReportArtifact, bool) {
+	params := mux.Vars(r)
+	report, ok := getPathReport(w, r)
+	if !ok {
+		return nil, false
+	}
+	artifacts, err := report.GetArtifacts(types...)
 	if err != nil {
-		log.WithError(err).WithFields(log.Fields{
-			"lhRequest": fmt.Sprintf("%+v", lhRequest),
-		}).Error("Could not run lighthouse\n", string(debug.Stack()))
+		log.WithError(err).WithField("report", report.ID).Error("Error getting report artifacts")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	if len(artifacts) == 0 {
+		http.Error(w, "Report "+params["id"]+" has no such artifacts. Request them by setting artifacts "+
+			"when creating the report.", http.StatusNotFound)
+		return nil, false
+	}
+	return artifacts, true
+}
+
+// @Summary Get the Lighthouse HTML report
+// @Description Returns the HTML report of reports that were created with the html artifact.
+// @Param id path string true "Report ID"
+// @Produce html
+// @Router /reports/{id}/html [get]
+func (a *App) getReportHTML(w http.ResponseWriter, r *http.Request) {
+	artifacts, ok := getReportArtifacts(w, r, pb.ArtifactType_HTML_REPORT)
+	if !ok {
 		return
 	}
-	report := NewReportFromRequest(&reportRequest)
-	if user := r.Context().Value("UserID"); user != nil {
-		log.WithField("user", user.(string)).Info("Creating report with user")
-		report.User = user.(string)
+	writeReportHTML(w, artifacts[0])
+}
+
+// writeReportHTML writes the lighthouse HTML report. The report can contain
+// the output of custom lighthouse configs and plugins, so it's sandboxed to
+// not run scripts with the origin of the API.
+func writeReportHTML(w http.ResponseWriter, a ReportArtifact) {
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Security-Policy", "sandbox allow-scripts")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(a.Data)
+}
+
+// @Summary Get the screenshots of a report
+// @Description Returns the final screenshot and the screenshots taken while the page was
+// @Description loading as data URIs for reports that were created with the screenshots artifact.
+// @Param id path string true "Report ID"
+// @Produce json
+// @Success 200 {object} api.Screenshots
+// @Router /reports/{id}/screenshots [get]
+func (a *App) getReportScreenshots(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	artifacts, ok := getReportArtifacts(w, r, pb.ArtifactType_FINAL_SCREENSHOT, pb.ArtifactType_SCREENSHOT_THUMBNAILS)
+	if !ok {
+		return
 	}
-	report.AuditResults, err = parseAuditResults(lhResult.GetStdout(), keys)
-	if err != nil {
-		log.WithError(err).Error("Error parsing audit results")
+	screenshots := newScreenshots(artifacts)
+	json.NewEncoder(w).Encode(&screenshots)
+}
+
+// @Summary Download the trace of a report
+// @Description Returns the devtools trace of reports that were created with the trace artifact.
+// @Param id path string true "Report ID"
+// @Produce json
+// @Router /reports/{id}/trace [get]
+func (a *App) getReportTrace(w http.ResponseWriter, r *http.Request) {
+	artifacts, ok := getReportArtifacts(w, r, pb.ArtifactType_TRACE)
+	if !ok {
+		return
 	}
-	report.PerformanceScore = parsePerformanceScore(lhResult.GetStdout())
-	report.RawJSON = string(lhResult.GetStdout())
-	if err := report.Insert(); err != nil {
-		log.WithError(err).Error("unable to insert report")
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	w.Header().Set("Content-Type", artifacts[0].ContentType)
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+mux.Vars(r)["id"]+"-trace.json\"")
+	w.Write(artifacts[0].Data)
+}
+
+// @Summary Download the debug bundle of a report
+// @Description Returns a gzipped tarball with the request, the lighthouse options, the
+// @Description environment, the lighthouse logs of every run and the lighthouse JSON of the report.
+// @Param id path string true "Report ID"
+// @Produce application/gzip
+// @Router /reports/{id}/debug [get]
+func (a *App) getReportDebug(w http.ResponseWriter, r *http.Request) {
+	report, ok := getPathReport(w, r)
+	if !ok {
 		return
 	}
-	if err = report.SendEmail(); err != nil {
-		log.WithError(err).WithField("report", report.ID).Error("Error sending email")
+	logs, err := report.GetArtifacts(pb.ArtifactType_LOGS)
+	if err != nil {
+		log.WithError(err).WithField("report", report.ID).Error("Error getting report logs")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
-	if !fullResult {
-		report.RawJSON = ""
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+report.ID.Hex()+"-debug.tar.gz\"")
+	if err := report.writeDebugBundle(w, logs); err != nil {
+		log.WithError(err).WithField("report", report.ID).Error("Error writing debug bundle")
 	}
-	json.NewEncoder(w).Encode(&report)
 }
 
-func (a *App) getReport(w http.ResponseWriter, r *http.Request) {
+// @Summary Get an async report job
+// @Description Returns the status of a job created with `POST /reports?async=true`. The
+// @Description field `report_id` is set once the report was created.
+// @Param id path string true "Job ID"
+// @Produce json
+// @Success 200 {object} api.Job
+// @Router /jobs/{id} [get]
+func (a *App) getJob(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	params := mux.Vars(r)
-	report, err := GetReportByObjectIDHex(params["id"])
+	job, err := a.loadJob(mux.Vars(r)["id"])
 	if err != nil {
 		if strings.Contains(err.Error(), "no documents in result") {
 			http.Error(w, err.Error(), http.StatusNotFound)
@@ -314,7 +689,102 @@ func (a *App) getReport(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	json.NewEncoder(w).Encode(&report)
+	json.NewEncoder(w).Encode(&job)
+}
+
+// @Summary Stream the progress of an async report job
+// @Description Server-sent events with the progress of a job created with `POST /reports?async=true`.
+// @Description The event types are queued, assigned (to a location), started, gathering and
+// @Description auditing for every lighthouse run, followed by completed or failed with the
+// @Description `report_id`. The stream ends after the completed or failed event. Reconnecting
+// @Description clients that send the `Last-Event-ID` header only receive the newer events.
+// @Param id path string true "Job ID"
+// @Produce text/event-stream
+// @Success 200 {object} api.JobEvent
+// @Router /jobs/{id}/events [get]
+func (a *App) getJobEvents(w http.ResponseWriter, r *http.Request) {
+	job, err := a.loadJob(mux.Vars(r)["id"])
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents in result") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming isn't supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	lastID, _ := strconv.Atoi(r.Header.Get("Last-Event-ID"))
+	keepalive := time.NewTicker(15 * time.Second)
+	defer keepalive.Stop()
+	events := a.jobEventLog(job.ID)
+	if events == nil {
+		// The job runs on another websu-api instance or finished a while
+		// ago, so only its status changes are sent.
+		a.pollJobEvents(w, r, flusher, &job, keepalive.C)
+		return
+	}
+	for {
+		newEvents, done, changed := events.since(lastID)
+		for _, e := range newEvents {
+			if err := e.write(w); err != nil {
+				return
+			}
+			lastID = e.ID
+		}
+		flusher.Flush()
+		if done {
+			return
+		}
+		select {
+		case <-changed:
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// pollJobEvents sends an event whenever the status of the job stored in
+// mongo changes, until the job finished.
+func (a *App) pollJobEvents(w http.ResponseWriter, r *http.Request, flusher http.Flusher, job *Job, keepalive <-chan time.Time) {
+	lastStatus := ""
+	poll := time.NewTicker(jobPollInterval)
+	defer poll.Stop()
+	for {
+		if job.Status != lastStatus {
+			lastStatus = job.Status
+			e := jobStatusEvent(job)
+			if err := e.write(w); err != nil {
+				return
+			}
+			if e.finished() {
+				flusher.Flush()
+				return
+			}
+		}
+		flusher.Flush()
+		select {
+		case <-poll.C:
+			updated, err := a.loadJob(job.ID.Hex())
+			if err != nil {
+				log.WithError(err).WithField("job", job.ID).Error("Error getting job")
+				continue
+			}
+			job = &updated
+		case <-keepalive:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+	}
 }
 
 func (a *App) deleteReport(w http.ResponseWriter, r *http.Request) {
@@ -342,6 +812,42 @@ func (a *App) getLocations(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(&locations)
 }
 
+type LighthouseVersions struct {
+	Location string   `json:"location"`
+	Versions []string `json:"versions" example:"9.4.0,9.6.0"`
+	Default  string   `json:"default" example:"9.4.0"`
+}
+
+// @Summary Get the Lighthouse versions of a location
+// @Description Returns the Lighthouse versions that can be requested with the lighthouse_version
+// @Description field of a report. The default location is used when location isn't set.
+// @Param location query string false "Location name"
+// @Produce json
+// @Success 200 {object} api.LighthouseVersions
+// @Router /lighthouse-versions [get]
+func (a *App) getLighthouseVersions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	location := r.URL.Query().Get("location")
+	if err := checkLocation(location); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	resp, err := lighthouseClient(location).Status(ctx, &pb.StatusRequest{})
+	if err != nil {
+		log.WithError(err).WithField("location", location).Error("Error getting lighthouse-server status")
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	versions := LighthouseVersions{
+		Location: location,
+		Versions: resp.GetLighthouseVersions(),
+		Default:  resp.GetDefaultLighthouseVersion(),
+	}
+	json.NewEncoder(w).Encode(&versions)
+}
+
 func (a *App) createLocation(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	location := NewLocation()
@@ -411,6 +917,87 @@ func (a *App) deleteLocation(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(&Report{})
 }
 
+// @Summary Get the custom Lighthouse configs
+// @Description Returns the custom Lighthouse configs that can be referenced with the
+// @Description lighthouse_config field of a report.
+// @Produce json
+// @Success 200 {array} api.LighthouseConfig
+// @Router /lighthouse-configs [get]
+func (a *App) getLighthouseConfigs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	configs, err := GetAllLighthouseConfigs()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(&configs)
+}
+
+// @Summary Get a custom Lighthouse config
+// @Param name path string true "Config name"
+// @Produce json
+// @Success 200 {object} api.LighthouseConfig
+// @Router /lighthouse-configs/{name} [get]
+func (a *App) getLighthouseConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	config, err := GetLighthouseConfigByName(mux.Vars(r)["name"])
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(&config)
+}
+
+// @Summary Create or replace a custom Lighthouse config
+// @Description Admin API to upload a Lighthouse config JSON, e.g. with custom audits,
+// @Description settings or plugins. Plugins must be installed on the lighthouse-servers.
+// @Accept json
+// @Param name path string true "Config name"
+// @Param LighthouseConfig body api.LighthouseConfig true "Description and config"
+// @Produce json
+// @Success 200 {object} api.LighthouseConfig
+// @Router /lighthouse-configs/{name} [put]
+func (a *App) putLighthouseConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	config := LighthouseConfig{}
+	if err := decodeJSONBody(w, r, &config); err != nil {
+		var mr *malformedRequest
+		if errors.As(err, &mr) {
+			http.Error(w, mr.msg, mr.status)
+		} else {
+			log.WithError(err).Error("Error decoding lighthouse config json")
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+	config.Name = mux.Vars(r)["name"]
+	if err := config.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.WithField("name", config.Name).Info("Saving lighthouse config")
+	if err := config.Upsert(); err != nil {
+		log.WithError(err).Error("Error saving lighthouse config")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(&config)
+}
+
+func (a *App) deleteLighthouseConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	config := LighthouseConfig{Name: mux.Vars(r)["name"]}
+	log.WithField("name", config.Name).Info("Deleting lighthouse config")
+	if err := config.Delete(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(&LighthouseConfig{})
+}
+
 func (a *App) ScheduledReportsGet(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	sr, err := GetAllScheduledReports()
@@ -435,9 +1022,16 @@ func (a *App) ScheduledReportsPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Infof("Decoded json from HTTP body. ScheduledReport: %+v", sr)
+	log.Infof("Decoded json from HTTP body. ScheduledReport: %+v", sr.redacted())
 	if err := sr.Validate(); err != nil {
-		log.WithError(err).WithField("ScheduledReport", sr).Info("Unable to validate ScheduledReport")
+		log.WithError(err).WithField("ScheduledReport", sr.redacted()).Info("Unable to validate ScheduledReport")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Only the scheduler may pass credentials that are already encrypted
+	sr.EncryptedCredentials = ""
+	if err := sr.encryptCredentials(); err != nil {
+		log.WithError(err).Info("Unable to encrypt the credentials of ScheduledReport")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -454,10 +1048,11 @@ func (a *App) ScheduledReportsPost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := sr.Insert(); err != nil {
-		log.WithError(err).WithField("sr", sr).Error("Error creating ScheduledReport")
+		log.WithError(err).WithField("sr", sr.redacted()).Error("Error creating ScheduledReport")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	sr.clearCredentials()
 	json.NewEncoder(w).Encode(&sr)
 }
 
@@ -472,6 +1067,45 @@ func (a *App) ScheduledReportGet(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(&sr)
 }
 
+// @Summary Run a scheduled report
+// @Description Creates a report of the scheduled report. This is used by the scheduler, which
+// @Description has to authenticate with the X-Scheduler-Token header. It's only available when
+// @Description websu-api has an encryption key, otherwise the scheduler uses POST /reports.
+// @Param id path string true "Scheduled Report ID"
+// @Produce json
+// @Success 200 {object} api.Report
+// @Router /scheduled-reports/{id}/run [post]
+func (a *App) runScheduledReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if len(EncryptionKey) == 0 {
+		http.Error(w, errNoSchedulerToken.Error(), http.StatusForbidden)
+		return
+	}
+	sr, err := getScheduledReportToRun(mux.Vars(r)["id"])
+	if err != nil {
+		if strings.Contains(err.Error(), "no documents in result") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+	if !validSchedulerToken(sr.ID, r.Header.Get(schedulerTokenHeader)) {
+		http.Error(w, "Invalid scheduler token", http.StatusForbidden)
+		return
+	}
+	rr := sr.ReportRequest
+	if err := rr.decryptCredentials(); err != nil {
+		log.WithError(err).WithField("ScheduledReport", sr.ID).Error("Unable to decrypt the credentials of ScheduledReport")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), reportTimeout(&rr))
+	defer cancel()
+	report, err := a.newReport(ctx, &rr, "", false)
+	writeNewReport(w, report, err, false)
+}
+
 func (a *App) ScheduledReportDelete(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	params := mux.Vars(r)
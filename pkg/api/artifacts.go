@@ -0,0 +1,164 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"time"
+
+	pb "github.com/websu-io/websu/pkg/lighthouse"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// artifactTypes maps the artifacts that can be requested in a ReportRequest
+// to the artifact types returned by lighthouse-server.
+var artifactTypes = map[string][]pb.ArtifactType{
+	"html":        {pb.ArtifactType_HTML_REPORT},
+	"screenshots": {pb.ArtifactType_FINAL_SCREENSHOT, pb.ArtifactType_SCREENSHOT_THUMBNAILS},
+	"trace":       {pb.ArtifactType_TRACE},
+}
+
+// ReportArtifact is a file produced by lighthouse for a report, like the HTML
+// report or a screenshot. Artifacts are stored in GridFS because they can be
+// bigger than the maximum size of a mongo document.
+type ReportArtifact struct {
+	ReportID    primitive.ObjectID `bson:"report_id"`
+	Type        string             `bson:"type"`
+	Name        string             `bson:"name"`
+	ContentType string             `bson:"content_type"`
+	// Timing is the time in milliseconds since navigation started at which
+	// a screenshot was taken
+	Timing float64 `bson:"timing"`
+	Data   []byte  `bson:"-"`
+}
+
+// Screenshot is a screenshot encoded as data URI, so it can be used as the
+// src of an img element directly.
+type Screenshot struct {
+	Timing float64 `json:"timing"`
+	Data   string  `json:"data"`
+}
+
+type Screenshots struct {
+	FinalScreenshot *Screenshot  `json:"final_screenshot"`
+	Thumbnails      []Screenshot `json:"thumbnails"`
+}
+
+func artifactsBucket() (*gridfs.Bucket, error) {
+	return gridfs.NewBucket(DB.Database(DatabaseName), options.GridFSBucket().SetName("report_artifacts"))
+}
+
+func (a ReportArtifact) dataURI() string {
+	return "data:" + a.ContentType + ";base64," + base64.StdEncoding.EncodeToString(a.Data)
+}
+
+// SaveArtifacts stores the artifacts that lighthouse returned for the report.
+func (report *Report) SaveArtifacts(artifacts []*pb.Artifact) error {
+	bucket, err := artifactsBucket()
+	if err != nil {
+		return err
+	}
+	for _, a := range artifacts {
+		metadata := ReportArtifact{
+			ReportID:    report.ID,
+			Type:        a.GetType().String(),
+			Name:        a.GetName(),
+			ContentType: a.GetContentType(),
+			Timing:      a.GetTiming(),
+		}
+		opts := options.GridFSUpload().SetMetadata(metadata)
+		filename := report.ID.Hex() + "/" + a.GetName()
+		if _, err := bucket.UploadFromStream(filename, bytes.NewReader(a.GetData()), opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type artifactFile struct {
+	ID       primitive.ObjectID `bson:"_id"`
+	Metadata ReportArtifact     `bson:"metadata"`
+}
+
+func findArtifactFiles(bucket *gridfs.Bucket, reportID primitive.ObjectID, types []pb.ArtifactType) ([]artifactFile, error) {
+	filter := bson.M{"metadata.report_id": reportID}
+	if len(types) > 0 {
+		names := []string{}
+		for _, t := range types {
+			names = append(names, t.String())
+		}
+		filter["metadata.type"] = bson.M{"$in": names}
+	}
+	opts := options.GridFSFind().SetSort(bson.M{"metadata.timing": 1})
+	cursor, err := bucket.Find(filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	files := []artifactFile{}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := cursor.All(ctx, &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// GetArtifacts returns the artifacts of the report with the given types or
+// all artifacts of the report when no types are given.
+func (report *Report) GetArtifacts(types ...pb.ArtifactType) ([]ReportArtifact, error) {
+	bucket, err := artifactsBucket()
+	if err != nil {
+		return nil, err
+	}
+	files, err := findArtifactFiles(bucket, report.ID, types)
+	if err != nil {
+		return nil, err
+	}
+	artifacts := []ReportArtifact{}
+	for _, f := range files {
+		var buf bytes.Buffer
+		if _, err := bucket.DownloadToStream(f.ID, &buf); err != nil {
+			return nil, err
+		}
+		a := f.Metadata
+		a.Data = buf.Bytes()
+		artifacts = append(artifacts, a)
+	}
+	return artifacts, nil
+}
+
+// DeleteArtifacts removes all artifacts of the report.
+func (report *Report) DeleteArtifacts() error {
+	bucket, err := artifactsBucket()
+	if err != nil {
+		return err
+	}
+	files, err := findArtifactFiles(bucket, report.ID, nil)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := bucket.Delete(f.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newScreenshots groups the screenshot artifacts of a report.
+func newScreenshots(artifacts []ReportArtifact) Screenshots {
+	s := Screenshots{Thumbnails: []Screenshot{}}
+	for _, a := range artifacts {
+		screenshot := Screenshot{Timing: a.Timing, Data: a.dataURI()}
+		switch a.Type {
+		case pb.ArtifactType_FINAL_SCREENSHOT.String():
+			s.FinalScreenshot = &screenshot
+		case pb.ArtifactType_SCREENSHOT_THUMBNAILS.String():
+			s.Thumbnails = append(s.Thumbnails, screenshot)
+		}
+	}
+	return s
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	pb "github.com/websu-io/websu/pkg/lighthouse"
+)
+
+func TestNewScreenshots(t *testing.T) {
+	artifacts := []ReportArtifact{
+		{Type: pb.ArtifactType_SCREENSHOT_THUMBNAILS.String(), ContentType: "image/jpeg", Timing: 300, Data: []byte{1, 2, 3}},
+		{Type: pb.ArtifactType_FINAL_SCREENSHOT.String(), ContentType: "image/jpeg", Timing: 980, Data: []byte{4, 5, 6}},
+	}
+	s := newScreenshots(artifacts)
+	if s.FinalScreenshot == nil || s.FinalScreenshot.Data != "data:image/jpeg;base64,BAUG" {
+		t.Errorf("Expected the final screenshot as data URI, but got %v", s.FinalScreenshot)
+	}
+	if len(s.Thumbnails) != 1 || s.Thumbnails[0].Timing != 300 || s.Thumbnails[0].Data != "data:image/jpeg;base64,AQID" {
+		t.Errorf("Unexpected thumbnails %v", s.Thumbnails)
+	}
+}
+
+func TestWriteReportHTML(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeReportHTML(w, ReportArtifact{ContentType: "text/html", Data: []byte("<html></html>")})
+	if csp := w.Header().Get("Content-Security-Policy"); csp != "sandbox allow-scripts" {
+		t.Errorf("Expected the HTML report to be sandboxed, but got Content-Security-Policy %q", csp)
+	}
+	if w.Header().Get("X-Content-Type-Options") != "nosniff" || w.Body.String() != "<html></html>" {
+		t.Errorf("Unexpected response %v %q", w.Header(), w.Body.String())
+	}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+
+	libredis "github.com/go-redis/redis/v8"
+)
+
+// ReportCacheTTL is how long the report of a request is reused for identical
+// requests. The cache is disabled when it's zero.
+var ReportCacheTTL = time.Duration(0)
+
+const reportCachePrefix = "websu:report-cache:"
+
+// reportCache maps the cache key of a report request to the ID of the report
+// that was created for it.
+type reportCache interface {
+	Get(ctx context.Context, key string) (string, bool, error)
+	Set(ctx context.Context, key string, reportID string, ttl time.Duration) error
+}
+
+// newReportCache returns a cache in Redis when a client is given and in memory
+// otherwise.
+func newReportCache(client *libredis.Client) reportCache {
+	if client != nil {
+		return &redisReportCache{client: client}
+	}
+	return newMemoryReportCache()
+}
+
+type redisReportCache struct {
+	client *libredis.Client
+}
+
+func (c *redisReportCache) Get(ctx context.Context, key string) (string, bool, error) {
+	id, err := c.client.Get(ctx, reportCachePrefix+key).Result()
+	if err == libredis.Nil {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	return id, true, nil
+}
+
+func (c *redisReportCache) Set(ctx context.Context, key string, reportID string, ttl time.Duration) error {
+	return c.client.Set(ctx, reportCachePrefix+key, reportID, ttl).Err()
+}
+
+type memoryCacheEntry struct {
+	reportID string
+	expires  time.Time
+}
+
+type memoryReportCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+}
+
+func newMemoryReportCache() *memoryReportCache {
+	return &memoryReportCache{entries: map[string]memoryCacheEntry{}}
+}
+
+func (c *memoryReportCache) Get(ctx context.Context, key string) (string, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		return "", false, nil
+	}
+	return e.reportID, true, nil
+}
+
+func (c *memoryReportCache) Set(ctx context.Context, key string, reportID string, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	// Expired entries are dropped on writes so the map doesn't grow forever
+	for k, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = memoryCacheEntry{reportID: reportID, expires: now.Add(ttl)}
+	return nil
+}
+
+// reportCacheKey returns a hash of the normalized URL, the run options and
+// the location of the request and the authenticated user. Reports belong to
+// their user, so they're only reused for the same user, and GET /reports
+// lists them. The user of the request body is ignored, so clients can't pick
+// the reports of another user. The email only affects who gets the report,
+// so it isn't part of the key. Headers and cookies are, so pages audited with
+// credentials are only reused for the same credentials.
+func reportCacheKey(rr *ReportRequest, user string) string {
+	key := *rr
+	key.URL = normalizeURL(rr.URL)
+	key.Email = ""
+	key.User = user
+	key.EncryptedCredentials = ""
+	if key.Runs < 1 {
+		key.Runs = 1
+	}
+	// Maps are marshalled with sorted keys, so the JSON is stable
+	b, _ := json.Marshal(key)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeURL lowercases the scheme and host and removes default ports,
+// fragments and the order of query parameters, so URLs that load the same
+// page share a cache key.
+func normalizeURL(s string) string {
+	u, err := neturl.Parse(s)
+	if err != nil {
+		return s
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port != "" && !(u.Scheme == "http" && port == "80") && !(u.Scheme == "https" && port == "443") {
+		host += ":" + port
+	}
+	u.Host = host
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.Fragment = ""
+	u.RawQuery = u.Query().Encode()
+	return u.String()
+}
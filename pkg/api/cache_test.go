@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/websu-io/websu/pkg/mocks"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := map[string]string{
+		"HTTPS://WWW.Google.com":                "https://www.google.com/",
+		"https://www.google.com:443/search#top": "https://www.google.com/search",
+		"http://www.google.com:80/?b=2&a=1":     "http://www.google.com/?a=1&b=2",
+		"http://localhost:8080/Path":            "http://localhost:8080/Path",
+	}
+	for url, expected := range tests {
+		if normalized := normalizeURL(url); normalized != expected {
+			t.Errorf("Expected %s to be normalized to %s, but got %s", url, expected, normalized)
+		}
+	}
+}
+
+func TestReportCacheKey(t *testing.T) {
+	rr := &ReportRequest{URL: "https://www.google.com", FormFactor: "desktop", Email: "a@websu.io"}
+	same := &ReportRequest{URL: "https://www.google.com/", FormFactor: "desktop", Email: "b@websu.io", Runs: 1}
+	if reportCacheKey(rr, "") != reportCacheKey(same, "") {
+		t.Error("Expected requests that only differ in email and URL format to share a cache key")
+	}
+	if reportCacheKey(rr, "user1") != reportCacheKey(&ReportRequest{URL: rr.URL, FormFactor: "desktop", User: "user2"}, "user1") {
+		t.Error("Expected the user of the request body to be ignored")
+	}
+	if reportCacheKey(rr, "user1") == reportCacheKey(rr, "user2") {
+		t.Error("Expected different users to have different cache keys")
+	}
+	different := []*ReportRequest{
+		{URL: "https://www.google.com", FormFactor: "mobile"},
+		{URL: "https://www.google.com", FormFactor: "desktop", Location: "europe-west1"},
+		{URL: "https://www.google.com", FormFactor: "desktop", Cookies: map[string]string{"session": "1"}},
+	}
+	for _, d := range different {
+		if reportCacheKey(rr, "") == reportCacheKey(d, "") {
+			t.Errorf("Expected %+v to have a different cache key", d)
+		}
+	}
+}
+
+func TestMemoryReportCache(t *testing.T) {
+	c := newMemoryReportCache()
+	ctx := context.Background()
+	if _, ok, _ := c.Get(ctx, "key"); ok {
+		t.Error("Expected a miss for an empty cache")
+	}
+	c.Set(ctx, "key", "report", time.Minute)
+	c.Set(ctx, "expired", "report", -time.Second)
+	if id, ok, _ := c.Get(ctx, "key"); !ok || id != "report" {
+		t.Errorf("Expected the cached report, but got %q %v", id, ok)
+	}
+	if _, ok, _ := c.Get(ctx, "expired"); ok {
+		t.Error("Expected a miss for an expired entry")
+	}
+}
+
+func TestCreateReportCachePerUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mocks.NewMockLighthouseServiceClient(ctrl)
+	defaultClient := LighthouseClient
+	LighthouseClient = client
+	defer func() { LighthouseClient = defaultClient }()
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+	}))
+	defer site.Close()
+	a := &App{reportCache: newMemoryReportCache()}
+	createReport := func(user string, bodyUser string) Report {
+		body := `{"url": "` + site.URL + `", "user": "` + bodyUser + `"}`
+		r := httptest.NewRequest("POST", "/reports", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		r = r.WithContext(context.WithValue(r.Context(), "UserID", user))
+		w := httptest.NewRecorder()
+		a.createReport(w, r)
+		var report Report
+		if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+			t.Fatalf("Error decoding report of %s: %v", user, err)
+		}
+		return report
+	}
+	// user1 and user2 run lighthouse, the second request of user1 is cached
+	expectResult(ctrl, client, `{}`)
+	expectResult(ctrl, client, `{}`)
+	first := createReport("user1", "")
+	if first.Cached || first.User != "user1" {
+		t.Errorf("Expected a new report of user1, but got %+v", first)
+	}
+	other := createReport("user2", "user1")
+	if other.Cached || other.ID == first.ID || other.User != "user2" {
+		t.Errorf("Expected a new report of user2, but got %+v", other)
+	}
+	cached := createReport("user1", "")
+	if !cached.Cached || cached.ID != first.ID {
+		t.Errorf("Expected the cached report of user1, but got %+v", cached)
+	}
+}
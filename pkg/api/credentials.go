@@ -0,0 +1,235 @@
+package api
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	pb "github.com/websu-io/websu/pkg/lighthouse"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EncryptionKey is the AES-256 key used to encrypt the extra headers and
+// cookies of scheduled reports before they're stored.
+var EncryptionKey []byte
+
+var (
+	errNoKey            = errors.New("Storing extra headers and cookies requires websu-api to be started with --encryption-key")
+	errNoSchedulerToken = errors.New("Running scheduled reports by ID requires websu-api to be started with --encryption-key")
+)
+
+// SetEncryptionKey sets EncryptionKey from a base64 encoded 32 byte key.
+func SetEncryptionKey(key string) error {
+	b, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return fmt.Errorf("Error decoding encryption key: %v", err)
+	}
+	if len(b) != 32 {
+		return fmt.Errorf("Encryption key must be 32 bytes long, but it's %d bytes long", len(b))
+	}
+	EncryptionKey = b
+	return nil
+}
+
+func newGCM() (cipher.AEAD, error) {
+	if len(EncryptionKey) == 0 {
+		return nil, errNoKey
+	}
+	block, err := aes.NewCipher(EncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encrypt encrypts plaintext with AES-GCM and returns the nonce followed by
+// the ciphertext encoded as base64.
+func encrypt(plaintext []byte) (string, error) {
+	gcm, err := newGCM()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+func decrypt(ciphertext string) ([]byte, error) {
+	gcm, err := newGCM()
+	if err != nil {
+		return nil, err
+	}
+	b, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < gcm.NonceSize() {
+		return nil, errors.New("Encrypted credentials are too short")
+	}
+	return gcm.Open(nil, b[:gcm.NonceSize()], b[gcm.NonceSize():], nil)
+}
+
+// schedulerTokenHeader is the header of the scheduler token that's required
+// to run a scheduled report.
+const schedulerTokenHeader = "X-Scheduler-Token"
+
+// schedulerToken returns the token that authenticates the scheduler when it
+// runs the scheduled report with the given ID or "" without EncryptionKey.
+func schedulerToken(id primitive.ObjectID) string {
+	if len(EncryptionKey) == 0 {
+		return ""
+	}
+	mac := hmac.New(sha256.New, EncryptionKey)
+	mac.Write([]byte("scheduled-report:" + id.Hex()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validSchedulerToken returns false without EncryptionKey, because there's
+// no token that authenticates the scheduler then.
+func validSchedulerToken(id primitive.ObjectID, token string) bool {
+	expected := schedulerToken(id)
+	return expected != "" && hmac.Equal([]byte(token), []byte(expected))
+}
+
+// reportCredentials are the secrets of a ReportRequest that are stored encrypted.
+type reportCredentials struct {
+	ExtraHeaders map[string]string `json:"extra_headers,omitempty"`
+	Cookies      map[string]string `json:"cookies,omitempty"`
+}
+
+func (r *ReportRequest) hasCredentials() bool {
+	return len(r.ExtraHeaders) > 0 || len(r.Cookies) > 0
+}
+
+// encryptCredentials moves the extra headers and cookies of the request to
+// EncryptedCredentials.
+func (r *ReportRequest) encryptCredentials() error {
+	if !r.hasCredentials() {
+		return nil
+	}
+	b, err := json.Marshal(reportCredentials{ExtraHeaders: r.ExtraHeaders, Cookies: r.Cookies})
+	if err != nil {
+		return err
+	}
+	if r.EncryptedCredentials, err = encrypt(b); err != nil {
+		return err
+	}
+	r.ExtraHeaders = nil
+	r.Cookies = nil
+	return nil
+}
+
+// decryptCredentials restores the extra headers and cookies of the request
+// from EncryptedCredentials. Headers and cookies that are set on the request
+// take precedence.
+func (r *ReportRequest) decryptCredentials() error {
+	if r.EncryptedCredentials == "" {
+		return nil
+	}
+	b, err := decrypt(r.EncryptedCredentials)
+	if err != nil {
+		return fmt.Errorf("Error decrypting credentials: %v", err)
+	}
+	var c reportCredentials
+	if err := json.Unmarshal(b, &c); err != nil {
+		return err
+	}
+	r.ExtraHeaders = mergeMaps(c.ExtraHeaders, r.ExtraHeaders)
+	r.Cookies = mergeMaps(c.Cookies, r.Cookies)
+	r.EncryptedCredentials = ""
+	return nil
+}
+
+// clearCredentials removes all credentials, so they aren't stored in plain
+// text or returned to clients.
+func (r *ReportRequest) clearCredentials() {
+	r.ExtraHeaders = nil
+	r.Cookies = nil
+	r.EncryptedCredentials = ""
+}
+
+// redacted returns a copy of the request without credentials for logging.
+func (r ReportRequest) redacted() ReportRequest {
+	r.clearCredentials()
+	return r
+}
+
+// lighthouseHeaders returns the extra headers passed to lighthouse with the
+// cookies added as Cookie header.
+func (r *ReportRequest) lighthouseHeaders() map[string]string {
+	if !r.hasCredentials() {
+		return nil
+	}
+	headers := mergeMaps(r.ExtraHeaders, nil)
+	if len(r.Cookies) > 0 {
+		names := []string{}
+		for name := range r.Cookies {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		cookies := []string{}
+		for _, name := range names {
+			cookies = append(cookies, name+"="+r.Cookies[name])
+		}
+		for name, value := range headers {
+			if strings.EqualFold(name, "Cookie") {
+				cookies = append([]string{value}, cookies...)
+				delete(headers, name)
+			}
+		}
+		headers["Cookie"] = strings.Join(cookies, "; ")
+	}
+	return headers
+}
+
+func mergeMaps(base map[string]string, overrides map[string]string) map[string]string {
+	if len(base) == 0 && len(overrides) == 0 {
+		return nil
+	}
+	merged := map[string]string{}
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
+
+func validateHeaders(value interface{}) error {
+	headers, _ := value.(map[string]string)
+	for name, v := range headers {
+		if !pb.HeaderNameRegexp.MatchString(name) {
+			return fmt.Errorf("Invalid header name %q", name)
+		}
+		if strings.ContainsAny(v, "\r\n") {
+			return fmt.Errorf("Value of header %q must not contain line breaks", name)
+		}
+	}
+	return nil
+}
+
+func validateCookies(value interface{}) error {
+	cookies, _ := value.(map[string]string)
+	for name, v := range cookies {
+		if !pb.HeaderNameRegexp.MatchString(name) {
+			return fmt.Errorf("Invalid cookie name %q", name)
+		}
+		if strings.ContainsAny(v, ";,\r\n") {
+			return fmt.Errorf("Value of cookie %q must not contain semicolons, commas or line breaks", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestEncryptCredentials(t *testing.T) {
+	defer func() { EncryptionKey = nil }()
+	if err := SetEncryptionKey(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))); err != nil {
+		t.Fatal(err)
+	}
+	rr := ReportRequest{
+		URL:          "https://www.google.com",
+		ExtraHeaders: map[string]string{"Authorization": "Bearer secret"},
+		Cookies:      map[string]string{"session": "abc"},
+	}
+	if err := rr.encryptCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	if rr.ExtraHeaders != nil || rr.Cookies != nil {
+		t.Error("Expected the plain text credentials to be removed")
+	}
+	if rr.EncryptedCredentials == "" || strings.Contains(rr.EncryptedCredentials, "secret") {
+		t.Errorf("Expected encrypted credentials, but got %q", rr.EncryptedCredentials)
+	}
+	if err := rr.decryptCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	if rr.ExtraHeaders["Authorization"] != "Bearer secret" || rr.Cookies["session"] != "abc" {
+		t.Errorf("Expected the decrypted credentials, but got %v and %v", rr.ExtraHeaders, rr.Cookies)
+	}
+}
+
+func TestEncryptCredentialsWithoutKey(t *testing.T) {
+	rr := ReportRequest{ExtraHeaders: map[string]string{"Authorization": "Bearer secret"}}
+	if err := rr.encryptCredentials(); err != errNoKey {
+		t.Errorf("Expected errNoKey, but got %v", err)
+	}
+}
+
+func TestSetEncryptionKeyInvalid(t *testing.T) {
+	if err := SetEncryptionKey(base64.StdEncoding.EncodeToString([]byte("short"))); err == nil {
+		t.Error("Expected an error for a key that isn't 32 bytes long")
+	}
+}
+
+func TestLighthouseHeaders(t *testing.T) {
+	rr := ReportRequest{
+		ExtraHeaders: map[string]string{"cookie": "a=1", "X-Test": "1"},
+		Cookies:      map[string]string{"session": "abc", "lang": "en"},
+	}
+	headers := rr.lighthouseHeaders()
+	if headers["Cookie"] != "a=1; lang=en; session=abc" {
+		t.Errorf("Expected the cookies to be merged into the Cookie header, but got %q", headers["Cookie"])
+	}
+	if _, ok := headers["cookie"]; ok || headers["X-Test"] != "1" {
+		t.Errorf("Unexpected headers %v", headers)
+	}
+	if rr.ExtraHeaders["cookie"] != "a=1" {
+		t.Error("Expected the extra headers of the request to be unchanged")
+	}
+}
+
+func TestValidateCredentials(t *testing.T) {
+	if err := validateHeaders(map[string]string{"Bad Header": "1"}); err == nil {
+		t.Error("Expected an error for an invalid header name")
+	}
+	if err := validateHeaders(map[string]string{"X-Test": "1\r\nX-Other: 2"}); err == nil {
+		t.Error("Expected an error for a header value with line breaks")
+	}
+	if err := validateCookies(map[string]string{"session": "abc; other=1"}); err == nil {
+		t.Error("Expected an error for a cookie value with a semicolon")
+	}
+}
+
+func TestValidateVerboseCredentials(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+	}))
+	defer site.Close()
+	rr := ReportRequest{URL: site.URL, Verbose: true}
+	if err := rr.Validate(); err != nil {
+		t.Errorf("Expected verbose logs without credentials to be valid, but got %v", err)
+	}
+	rr.Cookies = map[string]string{"session": "abc"}
+	if err := rr.Validate(); err == nil {
+		t.Error("Expected an error for verbose logs with cookies")
+	}
+}
+
+func TestDecodeReportRequestEncryptedCredentials(t *testing.T) {
+	defer func() { EncryptionKey = nil }()
+	if err := SetEncryptionKey(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))); err != nil {
+		t.Fatal(err)
+	}
+	rr := ReportRequest{URL: "https://www.google.com", ExtraHeaders: map[string]string{"Authorization": "Bearer secret"}}
+	if err := rr.encryptCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+	}))
+	defer site.Close()
+	body := `{"url": "` + site.URL + `", "encrypted_credentials": "` + rr.EncryptedCredentials + `"}`
+	r := httptest.NewRequest("POST", "/reports", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	decoded, ok := decodeReportRequest(httptest.NewRecorder(), r)
+	if !ok {
+		t.Fatal("Expected the report request to be decoded")
+	}
+	if decoded.EncryptedCredentials != "" || decoded.ExtraHeaders != nil {
+		t.Errorf("Expected the encrypted credentials of the client to be ignored, but got %+v", decoded)
+	}
+}
+
+func TestSchedulerToken(t *testing.T) {
+	defer func() { EncryptionKey = nil }()
+	id := primitive.NewObjectID()
+	if validSchedulerToken(id, "") {
+		t.Error("Expected no token to be valid without an encryption key")
+	}
+	if err := SetEncryptionKey(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))); err != nil {
+		t.Fatal(err)
+	}
+	if validSchedulerToken(id, "") || validSchedulerToken(id, schedulerToken(primitive.NewObjectID())) {
+		t.Error("Expected a missing token and the token of another scheduled report to be invalid")
+	}
+	if !validSchedulerToken(id, schedulerToken(id)) {
+		t.Error("Expected the token of the scheduled report to be valid")
+	}
+}
+
+func TestScheduledReportRunRequest(t *testing.T) {
+	defer func() { EncryptionKey = nil }()
+	sr := ScheduledReport{ID: primitive.NewObjectID(), ReportRequest: ReportRequest{URL: "https://www.google.com"}}
+	url, body, headers, err := scheduledReportRunRequest(sr)
+	if err != nil || !strings.HasSuffix(url, "/reports?cache=false") || !strings.Contains(string(body), sr.URL) {
+		t.Errorf("Expected the report request without an encryption key, but got %s %s %v", url, body, err)
+	}
+	if err := SetEncryptionKey(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))); err != nil {
+		t.Fatal(err)
+	}
+	url, body, headers, err = scheduledReportRunRequest(sr)
+	if err != nil || !strings.HasSuffix(url, "/scheduled-reports/"+sr.ID.Hex()+"/run") || body != nil ||
+		headers[schedulerTokenHeader] != schedulerToken(sr.ID) {
+		t.Errorf("Expected the ID and token of the scheduled report, but got %s %s %v %v", url, body, headers, err)
+	}
+}
+
+func TestRunScheduledReportWithoutKey(t *testing.T) {
+	a := &App{}
+	a.SetupRoutes()
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, httptest.NewRequest("POST", "/scheduled-reports/"+primitive.NewObjectID().Hex()+"/run", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 without an encryption key, but got %d", w.Code)
+	}
+}
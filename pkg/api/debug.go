@@ -0,0 +1,113 @@
+package api
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"path"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// debugEnvironment is the environment fingerprint of the debug bundle.
+type debugEnvironment struct {
+	Location          string        `json:"location"`
+	LighthouseVersion string        `json:"lighthouse_version"`
+	Environment       *Environment  `json:"environment"`
+	Unreliable        bool          `json:"unreliable"`
+	UnreliableReasons []string      `json:"unreliable_reasons,omitempty"`
+	RunResults        []Run         `json:"run_results,omitempty"`
+	Status            string        `json:"status"`
+	RuntimeError      *RuntimeError `json:"runtime_error,omitempty"`
+	RunWarnings       []string      `json:"run_warnings,omitempty"`
+	Attempts          []Attempt     `json:"attempts,omitempty"`
+	CreatedAt         time.Time     `json:"created_at"`
+}
+
+type bundleFile struct {
+	name string
+	data []byte
+}
+
+// sentLighthouseRequests returns the requests sent to lighthouse-server for
+// every attempt as JSON array. Reports that were created before the requests
+// were stored get the request rebuilt from the report request instead.
+func (report *Report) sentLighthouseRequests() ([]byte, error) {
+	requests := []json.RawMessage{}
+	for _, a := range report.Attempts {
+		if a.Request != "" {
+			requests = append(requests, json.RawMessage(a.Request))
+		}
+	}
+	if len(requests) == 0 {
+		rebuilt, err := protojson.Marshal(redactedLighthouseRequest(newLighthouseRequest(&report.ReportRequest)))
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, rebuilt)
+	}
+	return json.MarshalIndent(requests, "", "  ")
+}
+
+// writeDebugBundle writes a gzipped tarball with everything needed to debug
+// a run of the report: the request, the options sent to lighthouse-server
+// with every attempt, the environment, the logs and the lighthouse JSON.
+func (report *Report) writeDebugBundle(w io.Writer, logs []ReportArtifact) error {
+	request, err := json.MarshalIndent(report.ReportRequest, "", "  ")
+	if err != nil {
+		return err
+	}
+	options, err := report.sentLighthouseRequests()
+	if err != nil {
+		return err
+	}
+	environment, err := json.MarshalIndent(debugEnvironment{
+		Location:          report.Location,
+		LighthouseVersion: report.LighthouseVersion,
+		Environment:       report.Environment,
+		Unreliable:        report.Unreliable,
+		UnreliableReasons: report.UnreliableReasons,
+		RunResults:        report.RunResults,
+		Status:            report.Status,
+		RuntimeError:      report.RuntimeError,
+		RunWarnings:       report.RunWarnings,
+		Attempts:          report.Attempts,
+		CreatedAt:         report.CreatedAt,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := report.ID.Hex() + "-debug"
+	files := []bundleFile{
+		{"request.json", request},
+		{"options.json", options},
+		{"environment.json", environment},
+	}
+	for _, l := range logs {
+		files = append(files, bundleFile{"logs/" + l.Name, l.Data})
+	}
+	files = append(files, bundleFile{"lighthouse.json", []byte(report.RawJSON)})
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	for _, f := range files {
+		header := &tar.Header{
+			Name:    path.Join(dir, f.name),
+			Mode:    0644,
+			Size:    int64(len(f.data)),
+			ModTime: report.CreatedAt,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
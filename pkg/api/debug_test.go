@@ -0,0 +1,156 @@
+package api
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	pb "github.com/websu-io/websu/pkg/lighthouse"
+	"github.com/websu-io/websu/pkg/mocks"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func readBundle(t *testing.T, r io.Reader) map[string][]byte {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	files := map[string][]byte{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Files are keyed by their name inside the directory of the bundle
+		files[strings.SplitN(header.Name, "/", 2)[1]] = data
+	}
+}
+
+func TestWriteDebugBundle(t *testing.T) {
+	report := NewReport()
+	report.ID = primitive.NewObjectID()
+	report.URL = "https://www.google.com"
+	report.FormFactor = "mobile"
+	report.RawJSON = `{"lighthouseVersion": "9.4.0"}`
+	report.Environment = &Environment{CPUCount: 4}
+	logs := []ReportArtifact{{Type: pb.ArtifactType_LOGS.String(), Name: "lighthouse.log", Data: []byte("LH:status Auditing")}}
+	var buf bytes.Buffer
+	if err := report.writeDebugBundle(&buf, logs); err != nil {
+		t.Fatal(err)
+	}
+	files := readBundle(t, &buf)
+	if string(files["logs/lighthouse.log"]) != "LH:status Auditing" {
+		t.Errorf("Expected the logs in the bundle, but got %q", files["logs/lighthouse.log"])
+	}
+	if string(files["lighthouse.json"]) != report.RawJSON {
+		t.Errorf("Expected the lighthouse JSON in the bundle, but got %q", files["lighthouse.json"])
+	}
+	var options []map[string]interface{}
+	if err := json.Unmarshal(files["options.json"], &options); err != nil {
+		t.Fatal(err)
+	}
+	if len(options) != 1 || options[0]["formFactor"] != "MOBILE" {
+		t.Errorf("Expected the lighthouse options in the bundle, but got %v", options)
+	}
+	var env debugEnvironment
+	if err := json.Unmarshal(files["environment.json"], &env); err != nil {
+		t.Fatal(err)
+	}
+	if env.Environment == nil || env.Environment.CPUCount != 4 {
+		t.Errorf("Expected the environment in the bundle, but got %+v", env)
+	}
+	if _, ok := files["request.json"]; !ok {
+		t.Error("Expected the request in the bundle")
+	}
+}
+
+func TestWriteDebugBundleSentRequests(t *testing.T) {
+	withRetryPolicy(t, 2, false)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mocks.NewMockLighthouseServiceClient(ctrl)
+	LighthouseClient = client
+	client.EXPECT().RunStream(gomock.Any(), gomock.Any()).Return(nil, status.Error(codes.Unavailable, "connection refused"))
+	expectResult(ctrl, client, `{}`)
+
+	report := NewReport()
+	report.ID = primitive.NewObjectID()
+	report.URL = "https://www.google.com"
+	report.ExtraHeaders = map[string]string{"Authorization": "Bearer secret"}
+	req := newLighthouseRequest(&report.ReportRequest)
+	req.RequestId = report.ID.Hex()
+	req.ConfigJson = `{"extends": "lighthouse:default"}`
+	_, report.Attempts = runWithRetries(context.Background(), &report.ReportRequest, req, 1)
+	var buf bytes.Buffer
+	if err := report.writeDebugBundle(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	files := readBundle(t, &buf)
+	var options []json.RawMessage
+	if err := json.Unmarshal(files["options.json"], &options); err != nil {
+		t.Fatal(err)
+	}
+	if len(options) != 2 {
+		t.Fatalf("Expected the requests of both attempts in the bundle, but got %s", files["options.json"])
+	}
+	for i, data := range options {
+		o := &pb.LighthouseRequest{}
+		if err := protojson.Unmarshal(data, o); err != nil {
+			t.Fatal(err)
+		}
+		if expected := fmt.Sprintf("%s-run-1-attempt-%d", report.ID.Hex(), i+1); o.GetRequestId() != expected {
+			t.Errorf("Expected the request ID %v, but got %v", expected, o.GetRequestId())
+		}
+		if o.GetConfigJson() != req.ConfigJson {
+			t.Errorf("Expected the config that was sent, but got %q", o.GetConfigJson())
+		}
+		if o.GetExtraHeaders()["Authorization"] != "REDACTED" {
+			t.Errorf("Expected the headers to be redacted, but got %v", o.GetExtraHeaders())
+		}
+	}
+}
+
+func TestReportArtifacts(t *testing.T) {
+	logs := func(s string) *pb.Artifact {
+		return &pb.Artifact{Type: pb.ArtifactType_LOGS, Name: "lighthouse.log", Data: []byte(s)}
+	}
+	html := &pb.Artifact{Type: pb.ArtifactType_HTML_REPORT, Name: "report.html"}
+	outcomes := []runOutcome{
+		{number: 1, result: &pb.LighthouseResult{Artifacts: []*pb.Artifact{logs("run 1")}}},
+		{number: 2, result: &pb.LighthouseResult{Artifacts: []*pb.Artifact{html, logs("run 2")}}},
+		{number: 3, err: errors.New("lighthouse-server is unavailable")},
+	}
+	artifacts := reportArtifacts(outcomes, outcomes[1])
+	names := []string{}
+	for _, a := range artifacts {
+		names = append(names, a.GetName())
+	}
+	if len(names) != 3 || names[0] != "report.html" || names[1] != "run-1-lighthouse.log" || names[2] != "run-2-lighthouse.log" {
+		t.Errorf("Expected the HTML report of the shown run and the logs of every run, but got %v", names)
+	}
+	if outcomes[0].result.GetArtifacts()[0].GetName() != "lighthouse.log" {
+		t.Error("Expected the artifacts of the results to be unchanged")
+	}
+	if artifacts := reportArtifacts(outcomes[1:2], outcomes[1]); len(artifacts) != 2 || artifacts[1].GetName() != "lighthouse.log" {
+		t.Errorf("Expected the logs of a single run to keep their name, but got %v", artifacts)
+	}
+}
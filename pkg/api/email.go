@@ -5,9 +5,11 @@ import (
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"html/template"
+	"mime"
 	"net/smtp"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 var (
@@ -21,6 +23,23 @@ var (
 // used for tests
 var sendEmail = smtp.SendMail
 
+// emailSubjects are the localized subjects of the report email. The
+// templates/email-template.<language>.html template holds the localized body.
+var emailSubjects = map[string]string{
+	"de": "Websu: Performance-Bericht für %s",
+	"es": "Websu: Informe de rendimiento para %s",
+}
+
+// emailLanguage returns the language of locale that has a localized email
+// template or an empty string to use the English email.
+func emailLanguage(locale string) string {
+	language := strings.ToLower(strings.SplitN(locale, "-", 2)[0])
+	if _, ok := emailSubjects[language]; ok {
+		return language
+	}
+	return ""
+}
+
 func (report *Report) SendEmail() error {
 	// Set up authentication information.
 	auth := smtp.PlainAuth("", SmtpUsername, SmtpPassword, SmtpHost)
@@ -30,9 +49,15 @@ func (report *Report) SendEmail() error {
 	from := fmt.Sprintf("From: Websu <%s>\n", FromEmail)
 	to := fmt.Sprintf("To: %s\n", report.Email)
 	subject := fmt.Sprintf("Subject: Websu: Performance report for %s\n", report.URL)
-	mime := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"
+	templateName := "email-template.html"
+	if language := emailLanguage(report.Locale); language != "" {
+		subject = fmt.Sprintf("Subject: %s\n",
+			mime.QEncoding.Encode("utf-8", fmt.Sprintf(emailSubjects[language], report.URL)))
+		templateName = "email-template." + language + ".html"
+	}
+	mimeHeaders := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"
 	cwd, _ := os.Getwd()
-	templatePath := filepath.Join(cwd, "./templates/email-template.html")
+	templatePath := filepath.Join(cwd, "./templates", templateName)
 	t, err := template.ParseFiles(templatePath)
 	if err != nil {
 		return err
@@ -42,7 +67,7 @@ func (report *Report) SendEmail() error {
 		return err
 	}
 	body := buf.Bytes()
-	msg := append([]byte(from+to+subject+mime), body...)
+	msg := append([]byte(from+to+subject+mimeHeaders), body...)
 	toArr := []string{report.Email}
 	server := fmt.Sprintf("%s:%d", SmtpHost, SmtpPort)
 	err = sendEmail(server, auth, FromEmail, toArr, msg)
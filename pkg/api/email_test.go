@@ -56,3 +56,32 @@ func TestSendEmail(t *testing.T) {
 	}
 
 }
+
+func TestSendEmailLocalized(t *testing.T) {
+	actual := new(emailRecorder)
+	sendEmail = func(server string, auth smtp.Auth, fromEmail string, to []string, content []byte) error {
+		*actual = emailRecorder{server, auth, fromEmail, to, content}
+		return nil
+	}
+	r := NewReport()
+	r.URL = "https://www.google.com"
+	r.Email = "test@websu.io"
+	r.Locale = "de-AT"
+	if err := r.SendEmail(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(actual.Msg), "Neuer Performance-Bericht") {
+		t.Error("Expected the German email template for locale de-AT")
+	}
+	if !strings.Contains(string(actual.Msg), "Subject: =?utf-8?q?Websu:_Performance-Bericht_f=C3=BCr") {
+		t.Errorf("Expected an encoded German subject, but got %s", actual.Msg[:200])
+	}
+}
+
+func TestEmailLanguage(t *testing.T) {
+	for locale, expected := range map[string]string{"": "", "en-US": "", "es": "es", "DE": "de", "fr": ""} {
+		if got := emailLanguage(locale); got != expected {
+			t.Errorf("Expected email language %q for locale %q, but got %q", expected, locale, got)
+		}
+	}
+}
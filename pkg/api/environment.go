@@ -0,0 +1,72 @@
+package api
+
+import (
+	"fmt"
+
+	pb "github.com/websu-io/websu/pkg/lighthouse"
+)
+
+// Bounds of the run environment outside of which reports are flagged as
+// unreliable. Zero disables a bound.
+var (
+	// UnreliableMaxLoadPerCPU is the maximum load average per CPU of the host
+	// at the start or end of a run
+	UnreliableMaxLoadPerCPU = 1.0
+	// UnreliableMaxConcurrentRuns is the maximum number of runs executing on
+	// the lighthouse-server at the same time
+	UnreliableMaxConcurrentRuns = 0
+	// UnreliableMinBenchmarkIndex is the minimum lighthouse benchmarkIndex
+	UnreliableMinBenchmarkIndex = 0.0
+)
+
+// Environment describes the lighthouse-server host and the conditions of the
+// run that produced a report.
+type Environment struct {
+	CPUCount         int32   `json:"cpu_count" bson:"cpu_count"`
+	LoadAverageStart float64 `json:"load_average_start" bson:"load_average_start"`
+	LoadAverageEnd   float64 `json:"load_average_end" bson:"load_average_end"`
+	ConcurrentRuns   int32   `json:"concurrent_runs" bson:"concurrent_runs"`
+	ChromeVersion    string  `json:"chrome_version" bson:"chrome_version"`
+	BenchmarkIndex   float64 `json:"benchmark_index" bson:"benchmark_index"`
+}
+
+func newEnvironment(env *pb.Environment) *Environment {
+	if env == nil {
+		return nil
+	}
+	return &Environment{
+		CPUCount:         env.GetCpuCount(),
+		LoadAverageStart: env.GetLoadAverageStart(),
+		LoadAverageEnd:   env.GetLoadAverageEnd(),
+		ConcurrentRuns:   env.GetConcurrentRuns(),
+		ChromeVersion:    env.GetChromeVersion(),
+		BenchmarkIndex:   env.GetBenchmarkIndex(),
+	}
+}
+
+// unreliableReasons returns why the environment is outside of the configured
+// bounds or nil when the run is considered reliable.
+func (env *Environment) unreliableReasons() []string {
+	if env == nil {
+		return nil
+	}
+	var reasons []string
+	if UnreliableMaxLoadPerCPU > 0 && env.CPUCount > 0 {
+		for _, load := range []float64{env.LoadAverageStart, env.LoadAverageEnd} {
+			if perCPU := load / float64(env.CPUCount); perCPU > UnreliableMaxLoadPerCPU {
+				reasons = append(reasons, fmt.Sprintf("load average per CPU of %.2f exceeds %.2f",
+					perCPU, UnreliableMaxLoadPerCPU))
+				break
+			}
+		}
+	}
+	if UnreliableMaxConcurrentRuns > 0 && int(env.ConcurrentRuns) > UnreliableMaxConcurrentRuns {
+		reasons = append(reasons, fmt.Sprintf("%d concurrent runs exceed %d",
+			env.ConcurrentRuns, UnreliableMaxConcurrentRuns))
+	}
+	if UnreliableMinBenchmarkIndex > 0 && env.BenchmarkIndex > 0 && env.BenchmarkIndex < UnreliableMinBenchmarkIndex {
+		reasons = append(reasons, fmt.Sprintf("benchmark index of %.0f is below %.0f",
+			env.BenchmarkIndex, UnreliableMinBenchmarkIndex))
+	}
+	return reasons
+}
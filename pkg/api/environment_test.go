@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/url"
+	"testing"
+
+	pb "github.com/websu-io/websu/pkg/lighthouse"
+)
+
+func TestUnreliableReasons(t *testing.T) {
+	defer func(load float64, runs int, benchmark float64) {
+		UnreliableMaxLoadPerCPU, UnreliableMaxConcurrentRuns, UnreliableMinBenchmarkIndex = load, runs, benchmark
+	}(UnreliableMaxLoadPerCPU, UnreliableMaxConcurrentRuns, UnreliableMinBenchmarkIndex)
+	UnreliableMaxLoadPerCPU = 1
+	UnreliableMaxConcurrentRuns = 2
+	UnreliableMinBenchmarkIndex = 1000
+
+	env := newEnvironment(&pb.Environment{CpuCount: 4, LoadAverageStart: 1, LoadAverageEnd: 2,
+		ConcurrentRuns: 1, BenchmarkIndex: 1500})
+	if reasons := env.unreliableReasons(); len(reasons) != 0 {
+		t.Errorf("Expected a reliable environment, but got %v", reasons)
+	}
+	env = newEnvironment(&pb.Environment{CpuCount: 2, LoadAverageStart: 1, LoadAverageEnd: 5,
+		ConcurrentRuns: 3, BenchmarkIndex: 600})
+	if reasons := env.unreliableReasons(); len(reasons) != 3 {
+		t.Errorf("Expected the load, concurrent runs and benchmark index to be out of bounds, but got %v", reasons)
+	}
+	if reasons := newEnvironment(nil).unreliableReasons(); reasons != nil {
+		t.Errorf("Expected results without environment to be reliable, but got %v", reasons)
+	}
+}
+
+func TestReportsQueryUnreliable(t *testing.T) {
+	query, _, err := reportsQuery(url.Values{"unreliable": {"true"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query["unreliable"] != true {
+		t.Errorf("Expected a filter on unreliable reports, but got %v", query)
+	}
+	query, _, err = reportsQuery(url.Values{"unreliable": {"false"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := query["unreliable"].(map[string]interface{}); !ok {
+		t.Errorf("Expected a filter on reliable reports, but got %v", query)
+	}
+	if _, _, err := reportsQuery(url.Values{"unreliable": {"maybe"}}); err == nil {
+		t.Error("Expected an error for an invalid unreliable filter")
+	}
+}
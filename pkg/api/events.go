@@ -0,0 +1,199 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	pb "github.com/websu-io/websu/pkg/lighthouse"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The possible values of JobEvent.Type
+const (
+	JobEventQueued    = "queued"
+	JobEventAssigned  = "assigned"
+	JobEventStarted   = "started"
+	JobEventGathering = "gathering"
+	JobEventAuditing  = "auditing"
+	JobEventCompleted = "completed"
+	JobEventFailed    = "failed"
+)
+
+// jobEventsRetention is how long the events of a finished job are kept in
+// memory for clients that connect late.
+const jobEventsRetention = time.Minute
+
+// jobPollInterval is how often the status of jobs running on other websu-api
+// instances is read from mongo.
+var jobPollInterval = 2 * time.Second
+
+// progressEvents maps the progress stages of lighthouse-server to job events.
+// Completed runs aren't sent, because the job completes once the report of
+// all runs was stored.
+var progressEvents = map[pb.Progress_Stage]string{
+	pb.Progress_QUEUED:    JobEventQueued,
+	pb.Progress_STARTED:   JobEventStarted,
+	pb.Progress_GATHERING: JobEventGathering,
+	pb.Progress_AUDITING:  JobEventAuditing,
+}
+
+// JobEvent is a progress event of an async job sent by GET /jobs/{id}/events.
+type JobEvent struct {
+	// ID increases with every event of a job. It's 0 for events that were
+	// derived from the job status stored in mongo.
+	ID   int    `json:"id,omitempty"`
+	Type string `json:"type" example:"gathering"`
+	// Run and Attempt are set on the events of a lighthouse run
+	Run      int    `json:"run,omitempty"`
+	Attempt  int    `json:"attempt,omitempty"`
+	Location string `json:"location,omitempty"`
+	Message  string `json:"message,omitempty"`
+	// ReportID is set on completed and failed events when a report was stored
+	ReportID *primitive.ObjectID `json:"report_id,omitempty"`
+	Error    string              `json:"error,omitempty"`
+	Time     time.Time           `json:"time"`
+}
+
+func (e *JobEvent) finished() bool {
+	return e.Type == JobEventCompleted || e.Type == JobEventFailed
+}
+
+// write writes the event in the text/event-stream format.
+func (e *JobEvent) write(w io.Writer) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if e.ID > 0 {
+		fmt.Fprintf(w, "id: %d\n", e.ID)
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+	return err
+}
+
+// jobFinishedEvent returns the completed or failed event of a finished job.
+func jobFinishedEvent(job *Job) JobEvent {
+	e := JobEvent{Type: JobEventCompleted, ReportID: job.ReportID, Error: job.Error, Time: time.Now()}
+	if job.Status == JobStatusFailed {
+		e.Type = JobEventFailed
+	}
+	if job.FinishedAt != nil {
+		e.Time = *job.FinishedAt
+	}
+	return e
+}
+
+// jobStatusEvent returns the event matching the status of a job stored in
+// mongo, for jobs whose events aren't kept by this websu-api instance.
+func jobStatusEvent(job *Job) JobEvent {
+	switch job.Status {
+	case JobStatusQueued:
+		return JobEvent{Type: JobEventQueued, Time: job.CreatedAt}
+	case JobStatusRunning:
+		e := JobEvent{Type: JobEventStarted, Time: time.Now()}
+		if job.StartedAt != nil {
+			e.Time = *job.StartedAt
+		}
+		return e
+	default:
+		return jobFinishedEvent(job)
+	}
+}
+
+// jobEventLog keeps the events of a job that runs on this websu-api
+// instance, so clients that connect late receive all events.
+type jobEventLog struct {
+	mu     sync.Mutex
+	events []JobEvent
+	done   bool
+	// changed is closed and replaced when an event is published
+	changed chan struct{}
+}
+
+func newJobEventLog() *jobEventLog {
+	return &jobEventLog{changed: make(chan struct{})}
+}
+
+// publish appends an event. Events after the completed or failed event are
+// ignored.
+func (l *jobEventLog) publish(e JobEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.done {
+		return
+	}
+	e.ID = len(l.events) + 1
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	l.events = append(l.events, e)
+	l.done = e.finished()
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// since returns the events after the event with the given ID, whether the
+// job finished and a channel that's closed when the next event is published.
+func (l *jobEventLog) since(id int) ([]JobEvent, bool, <-chan struct{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if id < 0 || id > len(l.events) {
+		id = 0
+	}
+	events := append([]JobEvent{}, l.events[id:]...)
+	return events, l.done, l.changed
+}
+
+func (a *App) addJobEventLog(id primitive.ObjectID) *jobEventLog {
+	a.jobEventsMu.Lock()
+	defer a.jobEventsMu.Unlock()
+	l := newJobEventLog()
+	a.jobEvents[id] = l
+	return l
+}
+
+func (a *App) jobEventLog(id primitive.ObjectID) *jobEventLog {
+	a.jobEventsMu.Lock()
+	defer a.jobEventsMu.Unlock()
+	return a.jobEvents[id]
+}
+
+func (a *App) removeJobEventLog(id primitive.ObjectID) {
+	a.jobEventsMu.Lock()
+	defer a.jobEventsMu.Unlock()
+	delete(a.jobEvents, id)
+}
+
+type progressKey struct{}
+
+// withProgress returns a context that passes the progress events of the
+// lighthouse runs using it to publish.
+func withProgress(ctx context.Context, publish func(JobEvent)) context.Context {
+	return context.WithValue(ctx, progressKey{}, publish)
+}
+
+// withRunProgress returns a context that sets the run, attempt and location
+// on the progress events of ctx.
+func withRunProgress(ctx context.Context, run int, attempt int, location string) context.Context {
+	publish, ok := ctx.Value(progressKey{}).(func(JobEvent))
+	if !ok {
+		return ctx
+	}
+	return withProgress(ctx, func(e JobEvent) {
+		e.Run = run
+		e.Attempt = attempt
+		e.Location = location
+		publish(e)
+	})
+}
+
+// reportProgress publishes e when ctx was created by withProgress.
+func reportProgress(ctx context.Context, e JobEvent) {
+	if publish, ok := ctx.Value(progressKey{}).(func(JobEvent)); ok {
+		publish(e)
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	pb "github.com/websu-io/websu/pkg/lighthouse"
+	"github.com/websu-io/websu/pkg/mocks"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestJobEventLog(t *testing.T) {
+	l := newJobEventLog()
+	_, _, changed := l.since(0)
+	l.publish(JobEvent{Type: JobEventQueued})
+	select {
+	case <-changed:
+	default:
+		t.Error("Expected changed to be closed after publishing an event")
+	}
+	l.publish(JobEvent{Type: JobEventAssigned, Location: "europe-west1"})
+	events, done, _ := l.since(0)
+	if len(events) != 2 || events[0].ID != 1 || events[1].ID != 2 || done {
+		t.Errorf("Unexpected events %+v", events)
+	}
+	if events, _, _ := l.since(1); len(events) != 1 || events[0].Location != "europe-west1" {
+		t.Errorf("Expected only the events after the last event ID, but got %+v", events)
+	}
+	l.publish(JobEvent{Type: JobEventFailed, Error: "NO_FCP: The page did not paint any content."})
+	l.publish(JobEvent{Type: JobEventStarted})
+	if events, done, _ := l.since(2); len(events) != 1 || events[0].Type != JobEventFailed || !done {
+		t.Errorf("Expected the stream to end with the failed event, but got %+v", events)
+	}
+}
+
+func TestJobEventWrite(t *testing.T) {
+	var buf bytes.Buffer
+	e := JobEvent{ID: 3, Type: JobEventGathering, Run: 1, Message: "Loading page & waiting for onload",
+		Time: time.Date(2020, 12, 1, 0, 0, 0, 0, time.UTC)}
+	if err := e.write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := "id: 3\nevent: gathering\ndata: {\"id\":3,\"type\":\"gathering\",\"run\":1," +
+		"\"message\":\"Loading page \\u0026 waiting for onload\",\"time\":\"2020-12-01T00:00:00Z\"}\n\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, but got %q", expected, buf.String())
+	}
+}
+
+func TestJobStatusEvent(t *testing.T) {
+	reportID := primitive.NewObjectID()
+	tests := map[string]*Job{
+		JobEventQueued:    {Status: JobStatusQueued},
+		JobEventStarted:   {Status: JobStatusRunning},
+		JobEventCompleted: {Status: JobStatusDone, ReportID: &reportID},
+		JobEventFailed:    {Status: JobStatusFailed, Error: "connection refused"},
+	}
+	for expected, job := range tests {
+		e := jobStatusEvent(job)
+		if e.Type != expected || e.ReportID != job.ReportID || e.Error != job.Error {
+			t.Errorf("Expected a %s event for a %s job, but got %+v", expected, job.Status, e)
+		}
+	}
+}
+
+func TestRunProgressEvents(t *testing.T) {
+	withRetryPolicy(t, 1, false)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mocks.NewMockLighthouseServiceClient(ctrl)
+	LighthouseClient = client
+	stream := mocks.NewMockLighthouseService_RunStreamClient(ctrl)
+	progress := func(stage pb.Progress_Stage, message string) *pb.RunStreamResponse {
+		return &pb.RunStreamResponse{Event: &pb.RunStreamResponse_Progress{
+			Progress: &pb.Progress{Stage: stage, Message: message}}}
+	}
+	gomock.InOrder(
+		stream.EXPECT().Recv().Return(progress(pb.Progress_STARTED, ""), nil),
+		stream.EXPECT().Recv().Return(progress(pb.Progress_GATHERING, "Loading page & waiting for onload"), nil),
+		stream.EXPECT().Recv().Return(progress(pb.Progress_AUDITING, "Auditing: Speed Index"), nil),
+		stream.EXPECT().Recv().Return(progress(pb.Progress_COMPLETED, ""), nil),
+		stream.EXPECT().Recv().Return(&pb.RunStreamResponse{Event: &pb.RunStreamResponse_Chunk{
+			Chunk: &pb.ResultChunk{Data: []byte(`{}`), TotalSize: 2, Last: true}}}, nil),
+	)
+	client.EXPECT().RunStream(gomock.Any(), gomock.Any()).Return(stream, nil)
+
+	events := []JobEvent{}
+	ctx := withProgress(context.Background(), func(e JobEvent) { events = append(events, e) })
+	rr := &ReportRequest{URL: "https://www.google.com", Location: "europe-west1"}
+	runWithRetries(ctx, rr, newLighthouseRequest(rr), 2)
+	expected := []string{JobEventAssigned, JobEventStarted, JobEventGathering, JobEventAuditing}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected the events %v, but got %+v", expected, events)
+	}
+	for i, e := range events {
+		if e.Type != expected[i] || e.Run != 2 || e.Attempt != 1 || e.Location != "europe-west1" {
+			t.Errorf("Expected a %s event of run 2 in europe-west1, but got %+v", expected[i], e)
+		}
+	}
+}
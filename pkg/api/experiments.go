@@ -0,0 +1,48 @@
+package api
+
+import "fmt"
+
+// Experiment compares a baseline report with a report of the same page that
+// blocked the requests matching BlockedURLPatterns.
+type Experiment struct {
+	BlockedURLPatterns []string `json:"blocked_url_patterns"`
+	Baseline           *Report  `json:"baseline"`
+	Blocked            *Report  `json:"blocked"`
+	// Deltas maps performance_score and the metrics of the audit results to
+	// the value of the blocked report minus the value of the baseline report.
+	// Negative metric deltas mean that blocking made the page faster.
+	// Deltas are only set when both reports succeeded.
+	Deltas map[string]float64 `json:"deltas,omitempty"`
+	// Error explains why the experiment failed
+	Error string `json:"error,omitempty"`
+}
+
+// newExperiment compares the reports. Blocked is nil when it wasn't run,
+// because the baseline report already failed.
+func newExperiment(patterns []string, baseline *Report, blocked *Report) *Experiment {
+	e := &Experiment{
+		BlockedURLPatterns: patterns,
+		Baseline:           baseline,
+		Blocked:            blocked,
+	}
+	switch {
+	case baseline.Status == ReportStatusFailed:
+		e.Error = fmt.Sprintf("The baseline report failed: %s", baseline.RuntimeError)
+		return e
+	case blocked == nil:
+		e.Error = "The blocked report wasn't created"
+		return e
+	case blocked.Status == ReportStatusFailed:
+		e.Error = fmt.Sprintf("The blocked report failed: %s", blocked.RuntimeError)
+		return e
+	}
+	e.Deltas = map[string]float64{
+		"performance_score": float64(blocked.PerformanceScore - baseline.PerformanceScore),
+	}
+	for key, b := range baseline.AuditResults {
+		if v, ok := blocked.AuditResults[key]; ok {
+			e.Deltas[key] = v.NumericValue - b.NumericValue
+		}
+	}
+	return e
+}
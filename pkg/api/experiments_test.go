@@ -0,0 +1,50 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestNewExperiment(t *testing.T) {
+	baseline := &Report{PerformanceScore: 0.5, AuditResults: map[string]AuditResult{
+		"first-contentful-paint": {NumericValue: 2000},
+		"speed-index":            {NumericValue: 4000},
+	}}
+	blocked := &Report{PerformanceScore: 0.75, AuditResults: map[string]AuditResult{
+		"first-contentful-paint": {NumericValue: 1500},
+	}}
+	e := newExperiment([]string{"*.googletagmanager.com"}, baseline, blocked)
+	if e.Deltas["performance_score"] != 0.25 {
+		t.Errorf("Expected performance_score delta 0.25, but got %v", e.Deltas["performance_score"])
+	}
+	if e.Deltas["first-contentful-paint"] != -500 {
+		t.Errorf("Expected first-contentful-paint delta -500, but got %v", e.Deltas["first-contentful-paint"])
+	}
+	if _, ok := e.Deltas["speed-index"]; ok {
+		t.Errorf("Expected no delta for an audit missing from the blocked report")
+	}
+	if len(e.BlockedURLPatterns) != 1 {
+		t.Errorf("Expected the blocked url patterns, but got %v", e.BlockedURLPatterns)
+	}
+	if e.Error != "" {
+		t.Errorf("Expected no error, but got %s", e.Error)
+	}
+}
+
+func TestNewExperimentFailed(t *testing.T) {
+	succeeded := &Report{Status: ReportStatusSucceeded, PerformanceScore: 0.5}
+	failed := &Report{Status: ReportStatusFailed, RuntimeError: &RuntimeError{Code: "NO_FCP", Message: "No content"}}
+	tests := []struct {
+		baseline, blocked *Report
+	}{
+		{failed, nil},
+		{succeeded, failed},
+		{succeeded, nil},
+	}
+	for _, test := range tests {
+		e := newExperiment([]string{"*.googletagmanager.com"}, test.baseline, test.blocked)
+		if e.Deltas != nil || e.Error == "" || e.Baseline != test.baseline || e.Blocked != test.blocked {
+			t.Errorf("Expected an error and no deltas for the reports %+v and %+v, but got %+v",
+				test.baseline, test.blocked, e)
+		}
+	}
+}
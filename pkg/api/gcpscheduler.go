@@ -3,7 +3,6 @@ package api
 import (
 	cloudtasks "cloud.google.com/go/cloudtasks/apiv2"
 	"context"
-	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	taskspb "google.golang.org/genproto/googleapis/cloud/tasks/v2"
@@ -16,20 +15,20 @@ type GCPScheduler struct {
 }
 
 func (g GCPScheduler) RunReport(sr ScheduledReport) {
-	jsonBody, err := json.Marshal(sr.ReportRequest)
+	url, body, headers, err := scheduledReportRunRequest(sr)
 	if err != nil {
-		log.WithError(err).WithField("ScheduledReport", sr).Error("Unable to marshal http RunReport request")
+		log.WithError(err).WithField("ScheduledReport", sr.redacted()).Error("Unable to marshal http RunReport request")
 		return
 	}
-	_, err = CreateGCPCloudTask(g.Project, g.Location, g.Queue, ApiUrl+"/reports", jsonBody)
+	_, err = CreateGCPCloudTask(g.Project, g.Location, g.Queue, url, body, headers)
 	if err != nil {
-		log.WithError(err).WithField("ScheduledReport", sr).Error("Unable to create GCP cloud task")
+		log.WithError(err).WithField("ScheduledReport", sr.redacted()).Error("Unable to create GCP cloud task")
 		return
 	}
 
 }
 
-func CreateGCPCloudTask(projectID, locationID, queueID, url string, body []byte) (*taskspb.Task, error) {
+func CreateGCPCloudTask(projectID, locationID, queueID, url string, body []byte, headers map[string]string) (*taskspb.Task, error) {
 	// Create a new Cloud Tasks client instance.
 	// See https://godoc.org/cloud.google.com/go/cloudtasks/apiv2
 	ctx := context.Background()
@@ -51,6 +50,7 @@ func CreateGCPCloudTask(projectID, locationID, queueID, url string, body []byte)
 				HttpRequest: &taskspb.HttpRequest{
 					HttpMethod: taskspb.HttpMethod_POST,
 					Url:        url,
+					Headers:    headers,
 				},
 			},
 		},
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// JobWorkers is the number of async report jobs that run at the same time
+	JobWorkers = 2
+	// JobQueueSize is the number of async report jobs that can wait for a worker
+	JobQueueSize = 100
+	// JobRetention is how long jobs are kept after they were created
+	JobRetention = 7 * 24 * time.Hour
+	// JobTimeout is how long jobs may be queued and then run before they're
+	// failed, because the websu-api instance that had their request in memory
+	// was restarted. Jobs never time out when it's zero.
+	JobTimeout = time.Hour
+)
+
+// The possible values of Job.Status
+const (
+	JobStatusQueued  = "queued"
+	JobStatusRunning = "running"
+	JobStatusDone    = "done"
+	JobStatusFailed  = "failed"
+)
+
+var (
+	errJobQueueFull = errors.New("Too many reports are waiting to run, please retry later")
+	errJobTimedOut  = errors.New("The job didn't finish in time, e.g. because websu-api was restarted, please retry")
+)
+
+// Job is a report that's created asynchronously by one of the job workers
+// of websu-api. Jobs are stored in mongo, so every websu-api instance can
+// return their status, but the requests of queued jobs are only kept in
+// memory, because they can contain credentials.
+type Job struct {
+	ID     primitive.ObjectID `json:"id" bson:"_id"`
+	Status string             `json:"status" bson:"status" example:"queued"`
+	URL    string             `json:"url" bson:"url"`
+	User   string             `json:"-" bson:"user,omitempty"`
+	// ReportID is set when the report was created, including failed reports
+	ReportID *primitive.ObjectID `json:"report_id,omitempty" bson:"report_id,omitempty"`
+	// Error explains why the job failed
+	Error      string     `json:"error,omitempty" bson:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" bson:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty" bson:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty" bson:"finished_at,omitempty"`
+}
+
+func newJob(rr *ReportRequest, user string) *Job {
+	return &Job{
+		ID:        primitive.NewObjectID(),
+		Status:    JobStatusQueued,
+		URL:       rr.URL,
+		User:      user,
+		CreatedAt: time.Now(),
+	}
+}
+
+func jobs() *mongo.Collection {
+	return DB.Database(DatabaseName).Collection("jobs")
+}
+
+func (j *Job) Insert() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := jobs().InsertOne(ctx, j)
+	return err
+}
+
+// Update stores the status of the job.
+func (j *Job) Update() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := jobs().ReplaceOne(ctx, bson.M{"_id": j.ID}, j)
+	return err
+}
+
+// updateIf stores the status of the job when the stored job has one of the
+// given statuses and returns whether it was stored. This keeps websu-api
+// instances from overwriting a job that another instance finished.
+func (j *Job) updateIf(statuses ...string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := jobs().ReplaceOne(ctx, bson.M{"_id": j.ID, "status": bson.M{"$in": statuses}}, j)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+func GetJobByObjectIDHex(hex string) (Job, error) {
+	var job Job
+	oid, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		return job, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = jobs().FindOne(ctx, bson.M{"_id": oid}).Decode(&job)
+	return job, err
+}
+
+// stale returns true when the job is running for longer than JobTimeout or
+// didn't start within JobTimeout after it was created.
+func (j *Job) stale() bool {
+	if JobTimeout <= 0 {
+		return false
+	}
+	switch j.Status {
+	case JobStatusQueued:
+		return time.Since(j.CreatedAt) > JobTimeout
+	case JobStatusRunning:
+		return j.StartedAt != nil && time.Since(*j.StartedAt) > JobTimeout
+	default:
+		return false
+	}
+}
+
+// loadJob returns the job with the given ID. Stale jobs are failed, unless
+// they run on this websu-api instance, which knows that they didn't get lost.
+// The job is only failed when its status didn't change since it was read.
+func (a *App) loadJob(hex string) (Job, error) {
+	job, err := GetJobByObjectIDHex(hex)
+	if err != nil || !job.stale() || a.jobEventLog(job.ID) != nil {
+		return job, err
+	}
+	timedOut := job
+	timedOut.complete(nil, errJobTimedOut)
+	if failed, err := timedOut.updateIf(job.Status); err != nil {
+		log.WithError(err).WithField("job", job.ID).Error("Error updating job")
+		return job, nil
+	} else if failed {
+		return timedOut, nil
+	}
+	return GetJobByObjectIDHex(hex)
+}
+
+func (j *Job) start() {
+	now := time.Now()
+	j.Status = JobStatusRunning
+	j.StartedAt = &now
+}
+
+// complete sets the outcome of creating the report of the job. Jobs of
+// reports that were stored as failed are failed as well.
+func (j *Job) complete(report *Report, err error) {
+	now := time.Now()
+	j.FinishedAt = &now
+	if report != nil {
+		j.ReportID = &report.ID
+	}
+	switch {
+	case err != nil:
+		j.Status = JobStatusFailed
+		j.Error = err.Error()
+		if report != nil && report.RuntimeError != nil {
+			j.Error = report.RuntimeError.String()
+		}
+	case report.Status == ReportStatusFailed:
+		j.Status = JobStatusFailed
+		j.Error = report.RuntimeError.String()
+	default:
+		j.Status = JobStatusDone
+	}
+}
+
+type queuedJob struct {
+	job      *Job
+	request  *ReportRequest
+	useCache bool
+}
+
+// startJobWorkers starts the workers that create the reports of async jobs.
+func (a *App) startJobWorkers() {
+	a.jobs = make(chan queuedJob, JobQueueSize)
+	a.jobEvents = map[primitive.ObjectID]*jobEventLog{}
+	for i := 0; i < JobWorkers; i++ {
+		go func() {
+			for qj := range a.jobs {
+				a.runJob(qj)
+			}
+		}()
+	}
+}
+
+// enqueueJob stores a new job for the report request and queues it for the
+// workers.
+func (a *App) enqueueJob(rr *ReportRequest, user string, useCache bool) (*Job, error) {
+	job := newJob(rr, user)
+	if err := job.Insert(); err != nil {
+		return nil, err
+	}
+	events := a.addJobEventLog(job.ID)
+	events.publish(JobEvent{Type: JobEventQueued, Time: job.CreatedAt})
+	select {
+	case a.jobs <- queuedJob{job: job, request: rr, useCache: useCache}:
+		return job, nil
+	default:
+		a.removeJobEventLog(job.ID)
+		job.complete(nil, errJobQueueFull)
+		if err := job.Update(); err != nil {
+			log.WithError(err).WithField("job", job.ID).Error("Error updating job")
+		}
+		return nil, errJobQueueFull
+	}
+}
+
+func (a *App) runJob(qj queuedJob) {
+	job := qj.job
+	events := a.jobEventLog(job.ID)
+	// Other websu-api instances fail jobs that exceeded JobTimeout. Jobs
+	// that were failed before they started aren't run, and the outcome of a
+	// run doesn't replace the failure.
+	job.start()
+	stored, err := job.updateIf(JobStatusQueued)
+	if err != nil {
+		log.WithError(err).WithField("job", job.ID).Error("Error updating job")
+	}
+	if stored || err != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), reportTimeout(qj.request))
+		report, runErr := a.newReport(withProgress(ctx, events.publish), qj.request, job.User, qj.useCache)
+		cancel()
+		job.complete(report, runErr)
+		if stored, err = job.updateIf(JobStatusQueued, JobStatusRunning); err != nil {
+			log.WithError(err).WithField("job", job.ID).Error("Error updating job")
+		}
+	}
+	if !stored && err == nil {
+		if failed, err := GetJobByObjectIDHex(job.ID.Hex()); err != nil {
+			log.WithError(err).WithField("job", job.ID).Error("Error getting job")
+		} else {
+			job = &failed
+		}
+	}
+	events.publish(jobFinishedEvent(job))
+	time.AfterFunc(jobEventsRetention, func() { a.removeJobEventLog(job.ID) })
+	log.WithFields(log.Fields{"job": job.ID, "status": job.Status}).Info("Finished job")
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestJobComplete(t *testing.T) {
+	rr := &ReportRequest{URL: "https://websu.io"}
+	job := newJob(rr, "user")
+	if job.Status != JobStatusQueued || job.URL != rr.URL {
+		t.Errorf("Unexpected new job %+v", job)
+	}
+	job.start()
+	if job.Status != JobStatusRunning || job.StartedAt == nil {
+		t.Errorf("Expected a running job, but got %+v", job)
+	}
+
+	report := &Report{ID: primitive.NewObjectID(), Status: ReportStatusSucceeded}
+	job.complete(report, nil)
+	if job.Status != JobStatusDone || *job.ReportID != report.ID || job.FinishedAt == nil || job.Error != "" {
+		t.Errorf("Expected a done job with the report ID, but got %+v", job)
+	}
+
+	job = newJob(rr, "")
+	job.complete(nil, errors.New("connection refused"))
+	if job.Status != JobStatusFailed || job.ReportID != nil || job.Error != "connection refused" {
+		t.Errorf("Expected a failed job without report, but got %+v", job)
+	}
+
+	failed := &Report{ID: primitive.NewObjectID(), Status: ReportStatusFailed,
+		RuntimeError: &RuntimeError{Code: "NO_FCP", Message: "The page did not paint any content."}}
+	job = newJob(rr, "")
+	job.complete(failed, errors.New("exit status 1"))
+	if job.Status != JobStatusFailed || *job.ReportID != failed.ID || job.Error != failed.RuntimeError.String() {
+		t.Errorf("Expected a failed job with the failed report, but got %+v", job)
+	}
+}
+
+func TestJobStale(t *testing.T) {
+	job := newJob(&ReportRequest{URL: "https://websu.io"}, "")
+	if job.stale() {
+		t.Error("Expected a new job not to be stale")
+	}
+	job.CreatedAt = time.Now().Add(-JobTimeout - time.Minute)
+	if !job.stale() {
+		t.Error("Expected a queued job older than JobTimeout to be stale")
+	}
+	job.start()
+	if job.stale() {
+		t.Error("Expected the timeout of a running job to start with the run")
+	}
+	started := time.Now().Add(-JobTimeout - time.Minute)
+	job.StartedAt = &started
+	if !job.stale() {
+		t.Error("Expected a job running for longer than JobTimeout to be stale")
+	}
+	job.complete(nil, errJobTimedOut)
+	if job.stale() {
+		t.Error("Expected a finished job not to be stale")
+	}
+}
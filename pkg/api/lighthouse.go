@@ -0,0 +1,240 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	pb "github.com/websu-io/websu/pkg/lighthouse"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+var formFactors = map[string]pb.FormFactor{
+	"desktop": pb.FormFactor_DESKTOP,
+	"mobile":  pb.FormFactor_MOBILE,
+}
+
+// lighthouseClient returns the client of the lighthouse-server at location
+// or the default client when the location is unknown.
+func lighthouseClient(location string) pb.LighthouseServiceClient {
+	if client, ok := LighthouseClients[location]; ok {
+		return client
+	}
+	return LighthouseClient
+}
+
+// newLighthouseRequest maps a ReportRequest to the typed lighthouse options
+// understood by lighthouse-server.
+func newLighthouseRequest(rr *ReportRequest) *pb.LighthouseRequest {
+	req := &pb.LighthouseRequest{
+		Url:                rr.URL,
+		FormFactor:         formFactors[rr.FormFactor],
+		LighthouseVersion:  rr.LighthouseVersion,
+		ExtraHeaders:       rr.lighthouseHeaders(),
+		BlockedUrlPatterns: rr.BlockedURLPatterns,
+		Categories:         rr.Categories,
+		Locale:             rr.Locale,
+		ThrottlingMethod:   throttlingMethods[rr.ThrottlingMethod],
+		Throttling:         rr.lighthouseThrottling(),
+		Verbose:            rr.Verbose,
+	}
+	for _, a := range rr.Artifacts {
+		req.Artifacts = append(req.Artifacts, artifactTypes[a]...)
+	}
+	for _, step := range rr.Steps {
+		req.FlowSteps = append(req.FlowSteps, &pb.FlowStep{
+			Type:      pb.FlowStep_Type(pb.FlowStep_Type_value[strings.ToUpper(step.Type)]),
+			Url:       step.URL,
+			Selector:  step.Selector,
+			Text:      step.Text,
+			Name:      step.Name,
+			TimeoutMs: step.TimeoutMs,
+		})
+	}
+	return req
+}
+
+// redactedLighthouseRequest returns a copy of the request with the values of
+// the extra headers removed, so it can be logged and stored.
+func redactedLighthouseRequest(req *pb.LighthouseRequest) *pb.LighthouseRequest {
+	redacted := proto.Clone(req).(*pb.LighthouseRequest)
+	for name := range redacted.ExtraHeaders {
+		redacted.ExtraHeaders[name] = "REDACTED"
+	}
+	return redacted
+}
+
+// runLighthouse runs lighthouse using the streaming RunStream RPC and returns
+// the reassembled lighthouse JSON and artifacts, so results bigger than the
+// maximum gRPC message size can be received. Progress events of the stream
+// are published to the progress function of ctx.
+func runLighthouse(ctx context.Context, client pb.LighthouseServiceClient, req *pb.LighthouseRequest) (*pb.LighthouseResult, error) {
+	req.ResultCompression = pb.Compression_GZIP
+	stream, err := client.RunStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return pb.ReceiveResult(stream, func(p *pb.Progress) {
+		if t, ok := progressEvents[p.GetStage()]; ok {
+			reportProgress(ctx, JobEvent{Type: t, Message: p.GetMessage()})
+		}
+	})
+}
+
+// reportArtifacts returns the artifacts of the run that the report shows
+// and the logs of all runs, so failures of the other runs can be debugged.
+func reportArtifacts(outcomes []runOutcome, shown runOutcome) []*pb.Artifact {
+	artifacts := []*pb.Artifact{}
+	for _, a := range shown.result.GetArtifacts() {
+		if a.GetType() != pb.ArtifactType_LOGS {
+			artifacts = append(artifacts, a)
+		}
+	}
+	for _, o := range outcomes {
+		logs := o.logs()
+		if logs == nil {
+			continue
+		}
+		if len(outcomes) > 1 {
+			logs = proto.Clone(logs).(*pb.Artifact)
+			logs.Name = fmt.Sprintf("run-%d-%s", o.number, logs.GetName())
+		}
+		artifacts = append(artifacts, logs)
+	}
+	return artifacts
+}
+
+// runReport runs lighthouse for the request and stores the resulting report
+// and its artifacts. Reports of runs that failed are stored as well, unless
+// the request was invalid or wasn't attempted. lighthouse-server may return
+// a cached result when useCache is set.
+func runReport(ctx context.Context, rr *ReportRequest, user string, useCache bool) (*Report, error) {
+	runs := rr.Runs
+	if runs < 1 {
+		runs = 1
+	}
+	// The report ID is sent as request ID, so the lighthouse-server runs of
+	// the report can be identified.
+	reportID := primitive.NewObjectID()
+	lhRequest := newLighthouseRequest(rr)
+	lhRequest.RequestId = reportID.Hex()
+	// Every run of a report with multiple runs must run lighthouse, the
+	// median of cached copies of a single run would be meaningless
+	lhRequest.NoCache = !useCache || runs > 1
+	if rr.LighthouseConfig != "" {
+		config, err := GetLighthouseConfigByName(rr.LighthouseConfig)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Error getting lighthouse config %s: %v", rr.LighthouseConfig, err)
+		}
+		lhRequest.ConfigJson = string(config.Config)
+	}
+	outcomes := []runOutcome{}
+	attempts := []Attempt{}
+	for i := 0; i < runs; i++ {
+		o, runAttempts := runWithRetries(ctx, rr, lhRequest, i+1)
+		attempts = append(attempts, runAttempts...)
+		if o.err != nil {
+			log.WithError(o.err).WithFields(log.Fields{
+				"lhRequest": redactedLighthouseRequest(lhRequest).String(),
+				"run":       i + 1,
+				"attempts":  len(runAttempts),
+				"logs":      pb.ErrorLogs(o.err),
+			}).Error("Could not run lighthouse\n", string(debug.Stack()))
+			if !storeFailure(o.err) {
+				return nil, o.err
+			}
+		}
+		outcomes = append(outcomes, o)
+	}
+	report := NewReportFromRequest(rr)
+	report.ID = reportID
+	report.Attempts = attempts
+	if user != "" {
+		log.WithField("user", user).Info("Creating report with user")
+		report.User = user
+	}
+	succeeded := []runOutcome{}
+	failures := []string{}
+	for _, o := range outcomes {
+		if o.runtimeError == nil {
+			succeeded = append(succeeded, o)
+		} else if runs > 1 {
+			failures = append(failures, o.failureWarning())
+		}
+	}
+	// The first failed run is shown when all runs failed
+	shown := outcomes[0]
+	switch {
+	case len(succeeded) == 0:
+		report.Status = ReportStatusFailed
+		report.RuntimeError = shown.runtimeError
+	case len(succeeded) < len(outcomes):
+		report.Status = ReportStatusPartial
+	default:
+		report.Status = ReportStatusSucceeded
+	}
+	if len(succeeded) > 0 {
+		shown = succeeded[0]
+	}
+	if len(succeeded) > 1 {
+		for _, o := range succeeded {
+			stdout := o.result.GetStdout()
+			auditResults, err := parseAuditResults(stdout, keys)
+			if err != nil {
+				log.WithError(err).Error("Error parsing audit results of run")
+			}
+			report.RunResults = append(report.RunResults, newRun(parsePerformanceScore(stdout), auditResults))
+		}
+		report.MedianRun = medianRun(report.RunResults)
+		report.RunStats = newRunStats(report.RunResults)
+		shown = succeeded[report.MedianRun]
+	}
+	// Retries may have moved the run to another location
+	if shown.location != report.Location {
+		report.setLocation(shown.location)
+	}
+	result := shown.result
+	report.Environment = newEnvironment(result.GetEnvironment())
+	for _, o := range outcomes {
+		for _, reason := range newEnvironment(o.result.GetEnvironment()).unreliableReasons() {
+			if runs > 1 {
+				reason = fmt.Sprintf("run %d: %s", o.number, reason)
+			}
+			report.UnreliableReasons = append(report.UnreliableReasons, reason)
+		}
+	}
+	report.Unreliable = len(report.UnreliableReasons) > 0
+	stdout := result.GetStdout()
+	lhr := stdout
+	if len(rr.Steps) > 0 {
+		report.FlowSteps, lhr = parseFlowResult(stdout)
+	}
+	var err error
+	if lhr != nil {
+		report.AuditResults, err = parseAuditResults(lhr, keys)
+		if err != nil {
+			log.WithError(err).Error("Error parsing audit results")
+		}
+		report.PerformanceScore = parsePerformanceScore(lhr)
+		report.Scores = parseCategoryScores(lhr)
+		report.LighthouseVersion = parseLighthouseVersion(lhr)
+		report.RunWarnings = parseRunWarnings(lhr)
+	}
+	report.RunWarnings = append(report.RunWarnings, failures...)
+	report.RawJSON = string(stdout)
+	if err := report.Insert(); err != nil {
+		log.WithError(err).Error("unable to insert report")
+		return nil, err
+	}
+	if err := report.SaveArtifacts(reportArtifacts(outcomes, shown)); err != nil {
+		log.WithError(err).WithField("report", report.ID).Error("Error saving report artifacts")
+	}
+	// The error is returned with the stored report when lighthouse-server
+	// didn't return any result.
+	return report, shown.err
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var configNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// LighthouseConfig is a named custom lighthouse config that reports can
+// reference with lighthouse_config. Plugins listed in the config must be
+// installed on the lighthouse-server of the location.
+type LighthouseConfig struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	Name        string             `json:"name" bson:"name" example:"field-performance"`
+	Description string             `json:"description" bson:"description"`
+	// Config is the lighthouse config JSON, e.g. {"extends": "lighthouse:default", "plugins": [...]}
+	Config json.RawMessage `json:"config" bson:"-" swaggertype:"object"`
+	// ConfigJSON stores Config, because its keys aren't necessarily valid mongo field names
+	ConfigJSON string    `json:"-" bson:"config"`
+	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+func (c LighthouseConfig) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Name, validation.Required, validation.Match(configNameRegexp)),
+		validation.Field(&c.Config, validation.Required, validation.By(validateConfigJSON)),
+	)
+}
+
+func validateConfigJSON(value interface{}) error {
+	raw, _ := value.(json.RawMessage)
+	config := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return errors.New("must be a JSON object")
+	}
+	return nil
+}
+
+func lighthouseConfigs() *mongo.Collection {
+	return DB.Database(DatabaseName).Collection("lighthouse_configs")
+}
+
+// Upsert creates the config or replaces the config with the same name.
+func (c *LighthouseConfig) Upsert() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	now := time.Now()
+	update := bson.M{
+		"$set":         bson.M{"description": c.Description, "config": string(c.Config), "updated_at": now},
+		"$setOnInsert": bson.M{"_id": primitive.NewObjectID(), "created_at": now},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	if err := lighthouseConfigs().FindOneAndUpdate(ctx, bson.M{"name": c.Name}, update, opts).Decode(c); err != nil {
+		return err
+	}
+	c.Config = json.RawMessage(c.ConfigJSON)
+	return nil
+}
+
+func (c *LighthouseConfig) Delete() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := lighthouseConfigs().DeleteOne(ctx, bson.M{"name": c.Name})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return errors.New("Lighthouse config " + c.Name + " did not exist")
+	}
+	return nil
+}
+
+func GetAllLighthouseConfigs() ([]LighthouseConfig, error) {
+	configs := []LighthouseConfig{}
+	c := context.TODO()
+	cursor, err := lighthouseConfigs().Find(c, bson.M{}, options.Find().SetSort(bson.M{"name": 1}))
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(c, &configs); err != nil {
+		return nil, err
+	}
+	for i := range configs {
+		configs[i].Config = json.RawMessage(configs[i].ConfigJSON)
+	}
+	return configs, nil
+}
+
+func GetLighthouseConfigByName(name string) (LighthouseConfig, error) {
+	var config LighthouseConfig
+	if err := lighthouseConfigs().FindOne(context.Background(), bson.M{"name": name}).Decode(&config); err != nil {
+		return config, err
+	}
+	config.Config = json.RawMessage(config.ConfigJSON)
+	return config, nil
+}
+
+func checkLighthouseConfig(value interface{}) error {
+	s, _ := value.(string)
+	if s == "" {
+		return nil
+	}
+	if _, err := GetLighthouseConfigByName(s); err == mongo.ErrNoDocuments {
+		return fmt.Errorf("Lighthouse config %s doesn't exist", s)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestLighthouseConfigs(t *testing.T) {
+	c := LighthouseConfig{Name: "test-config", Config: json.RawMessage(`{"extends": "lighthouse:default"}`)}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Upsert(); err != nil {
+		t.Fatal(err)
+	}
+	id := c.ID
+	c.Config = json.RawMessage(`{"extends": "lighthouse:default", "settings": {"maxWaitForLoad": 60000}}`)
+	if err := c.Upsert(); err != nil {
+		t.Fatal(err)
+	}
+	if c.ID != id {
+		t.Errorf("Expected the ID %v to be kept when the config is replaced, but got %v", id, c.ID)
+	}
+	got, err := GetLighthouseConfigByName("test-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got.Config) != string(c.Config) {
+		t.Errorf("Expected config %s, but got %s", c.Config, got.Config)
+	}
+	if err := checkLighthouseConfig("test-config"); err != nil {
+		t.Error(err)
+	}
+	if err := c.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkLighthouseConfig("test-config"); err == nil {
+		t.Error("Expected an error for a deleted config")
+	}
+}
+
+func TestValidateLighthouseConfig(t *testing.T) {
+	invalid := []LighthouseConfig{
+		{Name: "Not a name", Config: json.RawMessage(`{}`)},
+		{Name: "array", Config: json.RawMessage(`[]`)},
+		{Name: "empty"},
+	}
+	for _, c := range invalid {
+		if err := c.Validate(); err == nil {
+			t.Errorf("Expected an error for config %+v", c)
+		}
+	}
+}
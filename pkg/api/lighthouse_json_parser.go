@@ -26,6 +26,26 @@ func parsePerformanceScore(rawJson []byte) float32 {
 	return float32(gjson.GetBytes(rawJson, "categories.performance.score").Float())
 }
 
+// parseCategoryScores returns the score of every category in the lighthouse
+// result. Categories without a score, such as performance in snapshots, are
+// left out.
+func parseCategoryScores(rawJson []byte) map[string]float32 {
+	scores := map[string]float32{}
+	gjson.GetBytes(rawJson, "categories").ForEach(func(id, category gjson.Result) bool {
+		if score := category.Get("score"); score.Exists() && score.Type != gjson.Null {
+			scores[id.String()] = float32(score.Float())
+		}
+		return true
+	})
+	return scores
+}
+
+// parseLighthouseVersion returns the version of lighthouse that created the
+// result.
+func parseLighthouseVersion(rawJson []byte) string {
+	return gjson.GetBytes(rawJson, "lighthouseVersion").String()
+}
+
 func parseAuditResults(rawJson []byte, keys []string) (map[string]AuditResult, error) {
 	res := lhJsonResult{}
 	if err := json.Unmarshal(rawJson, &res); err != nil {
@@ -54,3 +74,26 @@ func parseAuditResult(rawJson []byte) (*AuditResult, error) {
 	}
 	return ar, nil
 }
+
+// parseFlowResult returns the results of the steps of a lighthouse flow
+// result and the lighthouse result of its first navigation, which is used for
+// the audit results and performance score of the report.
+func parseFlowResult(rawJson []byte) ([]FlowStepResult, []byte) {
+	steps := []FlowStepResult{}
+	var navigation []byte
+	gjson.GetBytes(rawJson, "steps").ForEach(func(_, step gjson.Result) bool {
+		lhr := step.Get("lhr")
+		result := FlowStepResult{
+			Name:       step.Get("name").String(),
+			GatherMode: lhr.Get("gatherMode").String(),
+			URL:        lhr.Get("finalUrl").String(),
+			Scores:     parseCategoryScores([]byte(lhr.Raw)),
+		}
+		if navigation == nil && result.GatherMode == "navigation" {
+			navigation = []byte(lhr.Raw)
+		}
+		steps = append(steps, result)
+		return true
+	})
+	return steps, navigation
+}
@@ -89,3 +89,63 @@ func TestParsePerformanceScore(t *testing.T) {
 		t.Errorf("Expected 0.65 but got %v", got)
 	}
 }
+
+func TestParseLighthouseVersion(t *testing.T) {
+	testString := `
+{
+	"lighthouseVersion": "9.4.0",
+	"environment": {
+		"networkUserAgent": "Mozilla/5.0 (Macintosh) Chrome/98.0.4695.0 Safari/537.36 Chrome-Lighthouse",
+		"hostUserAgent": "Mozilla/5.0 (X11; Linux x86_64) HeadlessChrome/98.0.4758.102 Safari/537.36",
+		"benchmarkIndex": 1650.5
+	}
+}
+`
+	if version := parseLighthouseVersion([]byte(testString)); version != "9.4.0" {
+		t.Errorf("got lighthouse version: %s, but expected 9.4.0", version)
+	}
+}
+
+func TestParseFlowResult(t *testing.T) {
+	testString := `
+{
+	"name": "User flow",
+	"steps": [
+		{"name": "Home", "lhr": {"gatherMode": "navigation", "finalUrl": "https://www.google.com/",
+			"lighthouseVersion": "9.4.0",
+			"categories": {"performance": {"score": 0.9}, "seo": {"score": 1}}}},
+		{"name": "Search", "lhr": {"gatherMode": "timespan", "finalUrl": "https://www.google.com/search",
+			"categories": {"performance": {"score": null}, "best-practices": {"score": 0.5}}}}
+	]
+}
+`
+	steps, navigation := parseFlowResult([]byte(testString))
+	if len(steps) != 2 {
+		t.Fatalf("Expected 2 steps, but got %v", steps)
+	}
+	if steps[0].Name != "Home" || steps[0].GatherMode != "navigation" || steps[0].Scores["performance"] != 0.9 {
+		t.Errorf("Unexpected first step %+v", steps[0])
+	}
+	if _, ok := steps[1].Scores["performance"]; ok || steps[1].Scores["best-practices"] != 0.5 {
+		t.Errorf("Expected only the scored categories of the timespan, but got %v", steps[1].Scores)
+	}
+	if version := parseLighthouseVersion(navigation); version != "9.4.0" {
+		t.Errorf("Expected the lighthouse result of the navigation, but got %s", navigation)
+	}
+}
+
+func TestParseCategoryScores(t *testing.T) {
+	testString := `
+{
+  "categories": {
+    "performance": {"id": "performance", "score": 0.65},
+    "accessibility": {"id": "accessibility", "score": 0.9},
+    "pwa": {"id": "pwa", "score": null}
+  }
+}
+`
+	got := parseCategoryScores([]byte(testString))
+	if len(got) != 2 || got["performance"] != float32(0.65) || got["accessibility"] != float32(0.9) {
+		t.Errorf("Expected scores for performance and accessibility, but got %v", got)
+	}
+}
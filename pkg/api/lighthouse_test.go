@@ -0,0 +1,79 @@
+package api
+
+import (
+	"testing"
+
+	pb "github.com/websu-io/websu/pkg/lighthouse"
+)
+
+func TestNewLighthouseRequest(t *testing.T) {
+	rr := ReportRequest{URL: "https://www.google.com", FormFactor: "mobile", ThroughputKbps: 5000}
+	req := newLighthouseRequest(&rr)
+	if req.GetFormFactor() != pb.FormFactor_MOBILE {
+		t.Errorf("Expected form factor MOBILE, but got %v", req.GetFormFactor())
+	}
+	if req.GetThrottling().GetThroughputKbps() != 5000 {
+		t.Errorf("Expected throughput 5000, but got %v", req.GetThrottling().GetThroughputKbps())
+	}
+	if len(req.GetOptions()) != 0 {
+		t.Errorf("Expected no raw lighthouse options, but got %v", req.GetOptions())
+	}
+	if len(req.GetArtifacts()) != 0 {
+		t.Errorf("Expected no artifacts, but got %v", req.GetArtifacts())
+	}
+}
+
+func TestNewLighthouseRequestArtifacts(t *testing.T) {
+	rr := ReportRequest{URL: "https://www.google.com", Artifacts: []string{"html", "screenshots"}}
+	got := newLighthouseRequest(&rr).GetArtifacts()
+	expected := []pb.ArtifactType{pb.ArtifactType_HTML_REPORT, pb.ArtifactType_FINAL_SCREENSHOT,
+		pb.ArtifactType_SCREENSHOT_THUMBNAILS}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected artifacts %v, but got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Expected artifacts %v, but got %v", expected, got)
+		}
+	}
+}
+
+func TestNewLighthouseRequestFlowSteps(t *testing.T) {
+	rr := ReportRequest{URL: "https://www.google.com", Steps: []FlowStep{
+		{Type: "wait_for_selector", Selector: "#search", TimeoutMs: 5000},
+		{Type: "snapshot", Name: "Search box"},
+	}}
+	steps := newLighthouseRequest(&rr).GetFlowSteps()
+	if len(steps) != 2 {
+		t.Fatalf("Expected 2 flow steps, but got %v", steps)
+	}
+	if steps[0].GetType() != pb.FlowStep_WAIT_FOR_SELECTOR || steps[0].GetTimeoutMs() != 5000 {
+		t.Errorf("Unexpected first flow step %v", steps[0])
+	}
+	if steps[1].GetType() != pb.FlowStep_SNAPSHOT || steps[1].GetName() != "Search box" {
+		t.Errorf("Unexpected second flow step %v", steps[1])
+	}
+}
+
+func TestNewLighthouseRequestBlockedURLPatterns(t *testing.T) {
+	rr := ReportRequest{URL: "https://www.google.com", BlockedURLPatterns: []string{"*.googletagmanager.com"}}
+	got := newLighthouseRequest(&rr).GetBlockedUrlPatterns()
+	if len(got) != 1 || got[0] != "*.googletagmanager.com" {
+		t.Errorf("Expected blocked url patterns [*.googletagmanager.com], but got %v", got)
+	}
+}
+
+func TestNewLighthouseRequestCategories(t *testing.T) {
+	rr := ReportRequest{URL: "https://www.google.com", Categories: []string{"accessibility", "pwa"}}
+	got := newLighthouseRequest(&rr).GetCategories()
+	if len(got) != 2 || got[0] != "accessibility" || got[1] != "pwa" {
+		t.Errorf("Expected categories [accessibility pwa], but got %v", got)
+	}
+}
+
+func TestNewLighthouseRequestLocale(t *testing.T) {
+	rr := ReportRequest{URL: "https://www.google.com", Locale: "pt-BR"}
+	if got := newLighthouseRequest(&rr).GetLocale(); got != "pt-BR" {
+		t.Errorf("Expected locale pt-BR, but got %q", got)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	neturl "net/url"
 	"reflect"
+	"regexp"
 	"strings"
 	"time"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/go-ozzo/ozzo-validation/v4/is"
 	"github.com/jinzhu/copier"
 	log "github.com/sirupsen/logrus"
+	pb "github.com/websu-io/websu/pkg/lighthouse"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -25,6 +27,18 @@ var (
 	DatabaseName = "websu"
 )
 
+var urlPatternRegexp = regexp.MustCompile(`^\S+$`)
+
+func categoryNames() []interface{} {
+	names := []interface{}{}
+	for _, c := range pb.Categories {
+		names = append(names, c)
+	}
+	return names
+}
+
+var lighthouseVersionRegexp = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+([.-][0-9a-zA-Z.-]+)?$`)
+
 func CreateMongoClient(mongoURI string) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -43,13 +57,84 @@ type ReportRequest struct {
 	URL string `json:"url" bson:"url" example:"https://www.google.com"`
 	// Optional parameter, possible values are desktop or mobile. If unset will default to desktop
 	FormFactor string `json:"form_factor" bson:"form_factor" example:"desktop"`
-	// Optional parameter, by default will be set to 1000 if omitted
+	// Optional parameter, by default will be set to 1000 if omitted and no throttling preset is set
 	ThroughputKbps int64 `json:"throughput_kbps" bson:"thoughput_kbps" example:"50000"`
+	// Optional parameter, possible values are slow-3g, fast-3g, 4g, cable and none.
+	// The throughput_kbps and throttling values override the values of the preset.
+	ThrottlingPreset string `json:"throttling_preset,omitempty" bson:"throttling_preset,omitempty" example:"fast-3g"`
+	// Optional parameter, custom throttling. Reports contain the throttling that was used.
+	Throttling *Throttling `json:"throttling,omitempty" bson:"throttling,omitempty"`
+	// Optional parameter, possible values are simulate, devtools and provided. Defaults to simulate,
+	// or provided for the none preset.
+	ThrottlingMethod string `json:"throttling_method,omitempty" bson:"throttling_method,omitempty" example:"simulate"`
 	// Optional parameter, default location will be used if not set
 	Location string `json:"location" bson:"location" example:"australia-southeast1"`
 	// Optional parameter, email adress to sent the report to
 	Email string `json:"email,omitempty" bson:"email"`
 	User  string `json:"user,omitempty" bson:"user"`
+	// Optional parameter, artifacts to keep in addition to the lighthouse JSON.
+	// Possible values are html, screenshots and trace.
+	Artifacts []string `json:"artifacts,omitempty" bson:"artifacts,omitempty" example:"html,screenshots"`
+	// Optional parameter, the lighthouse version to run. The default version of the location
+	// is used when not set. Reports contain the version that produced them.
+	LighthouseVersion string `json:"lighthouse_version,omitempty" bson:"lighthouse_version,omitempty" example:"9.4.0"`
+	// Optional parameter, HTTP headers sent with every request of the audit, e.g. Authorization.
+	// Headers are only stored encrypted for scheduled reports and never returned.
+	ExtraHeaders map[string]string `json:"extra_headers,omitempty" bson:"-"`
+	// Optional parameter, cookies sent with every request of the audit. Cookies are only
+	// stored encrypted for scheduled reports and never returned.
+	Cookies map[string]string `json:"cookies,omitempty" bson:"-"`
+	// EncryptedCredentials holds the encrypted headers and cookies of scheduled reports
+	EncryptedCredentials string `json:"encrypted_credentials,omitempty" bson:"encrypted_credentials,omitempty" swaggerignore:"true"`
+	// Optional parameter, runs a user flow with these steps instead of a single page load.
+	// The flow starts by navigating to URL when the first step isn't a navigation.
+	Steps []FlowStep `json:"steps,omitempty" bson:"steps,omitempty"`
+	// Optional parameter, requests matching these patterns are blocked during the audit.
+	// Patterns may contain * wildcards, e.g. *.googletagmanager.com
+	BlockedURLPatterns []string `json:"blocked_url_patterns,omitempty" bson:"blocked_url_patterns,omitempty" example:"*.googletagmanager.com"`
+	// Optional parameter, the lighthouse categories to audit. Possible values are performance,
+	// accessibility, best-practices, seo and pwa. Defaults to best-practices, performance and seo.
+	Categories []string `json:"categories,omitempty" bson:"categories,omitempty" example:"performance,accessibility"`
+	// Optional parameter, the locale of the lighthouse report and the report email, e.g. de or pt-BR.
+	// Defaults to en-US.
+	Locale string `json:"locale,omitempty" bson:"locale,omitempty" example:"de"`
+	// Optional parameter, the name of a custom lighthouse config that was uploaded by an admin
+	LighthouseConfig string `json:"lighthouse_config,omitempty" bson:"lighthouse_config,omitempty" example:"field-performance"`
+	// Optional parameter, the number of lighthouse runs between 1 and 5. The report contains the
+	// median run and the metrics of all runs. Defaults to 1.
+	Runs int `json:"runs,omitempty" bson:"runs,omitempty" example:"3"`
+	// Optional parameter, runs lighthouse with verbose logging. The logs are part of the debug
+	// bundle of the report. Verbose logs contain the extra headers, so it can't be combined
+	// with extra headers or cookies.
+	Verbose bool `json:"verbose,omitempty" bson:"verbose,omitempty"`
+}
+
+// FlowStep is a step of a user flow
+type FlowStep struct {
+	// Possible values are navigate, click, type, wait_for_selector, start_timespan,
+	// end_timespan and snapshot
+	Type string `json:"type" bson:"type" example:"click"`
+	// The URL of navigate steps
+	URL string `json:"url,omitempty" bson:"url,omitempty"`
+	// The CSS selector of the element used by click, type and wait_for_selector steps
+	Selector string `json:"selector,omitempty" bson:"selector,omitempty" example:"#login"`
+	// The text typed by type steps
+	Text string `json:"text,omitempty" bson:"text,omitempty"`
+	// Optional name of the step in the report
+	Name string `json:"name,omitempty" bson:"name,omitempty"`
+	// Optional timeout of wait_for_selector steps, by default 30000
+	TimeoutMs int32 `json:"timeout_ms,omitempty" bson:"timeout_ms,omitempty"`
+}
+
+func (s FlowStep) Validate() error {
+	needsSelector := s.Type == "click" || s.Type == "type" || s.Type == "wait_for_selector"
+	return validation.ValidateStruct(&s,
+		validation.Field(&s.Type, validation.Required, validation.In("navigate", "click", "type",
+			"wait_for_selector", "start_timespan", "end_timespan", "snapshot")),
+		validation.Field(&s.URL, validation.When(s.Type == "navigate", validation.Required), is.URL),
+		validation.Field(&s.Selector, validation.When(needsSelector, validation.Required)),
+		validation.Field(&s.TimeoutMs, validation.Min(0), validation.Max(120000)),
+	)
 }
 
 func validateURL(value interface{}) error {
@@ -94,8 +179,24 @@ func (r ReportRequest) Validate() error {
 		validation.Field(&r.URL, validation.Required, is.URL, validation.By(validateURL)),
 		validation.Field(&r.FormFactor, validation.In("desktop", "mobile")),
 		validation.Field(&r.ThroughputKbps, validation.Min(1000), validation.Max(500000)),
+		validation.Field(&r.ThrottlingPreset, validation.In(throttlingPresetNames()...)),
+		validation.Field(&r.Throttling),
+		validation.Field(&r.ThrottlingMethod, validation.In(throttlingMethodNames()...)),
 		validation.Field(&r.Location, validation.By(checkLocation)),
 		validation.Field(&r.Email, is.Email),
+		validation.Field(&r.Artifacts, validation.Each(validation.In("html", "screenshots", "trace"))),
+		validation.Field(&r.LighthouseVersion, validation.Match(lighthouseVersionRegexp)),
+		validation.Field(&r.ExtraHeaders, validation.By(validateHeaders)),
+		validation.Field(&r.Cookies, validation.By(validateCookies)),
+		validation.Field(&r.Verbose, validation.When(r.hasCredentials(),
+			validation.Empty.Error("verbose logs can't be combined with extra headers or cookies"))),
+		validation.Field(&r.Steps, validation.Length(0, pb.MaxFlowSteps)),
+		validation.Field(&r.BlockedURLPatterns, validation.Each(validation.Required, validation.Match(urlPatternRegexp))),
+		validation.Field(&r.Categories, validation.Each(validation.In(categoryNames()...))),
+		validation.Field(&r.Locale, validation.Match(pb.LocaleRegexp)),
+		validation.Field(&r.LighthouseConfig, validation.By(checkLighthouseConfig)),
+		validation.Field(&r.Runs, validation.Min(1), validation.Max(MaxRuns),
+			validation.When(len(r.Steps) > 0, validation.Max(1).Error("user flows support a single run"))),
 	)
 }
 
@@ -107,6 +208,13 @@ type ScheduledReport struct {
 	CreatedAt     time.Time `json:"created_at" bson:"created_at"`
 }
 
+// redacted returns a copy of the scheduled report without credentials for
+// logging.
+func (s ScheduledReport) redacted() ScheduledReport {
+	s.ReportRequest = s.ReportRequest.redacted()
+	return s
+}
+
 func (s ScheduledReport) Validate() error {
 	return validation.ValidateStruct(&s,
 		validation.Field(&s.ReportRequest),
@@ -123,6 +231,45 @@ type Report struct {
 	CreatedAt        time.Time              `json:"created_at" bson:"created_at"`
 	PerformanceScore float32                `json:"performance_score" bson:"performance_score"`
 	AuditResults     map[string]AuditResult `json:"audit_results" bson:"audit_results"`
+	// Scores maps the audited categories to their score between 0 and 1
+	Scores map[string]float32 `json:"scores" bson:"scores"`
+	// FlowSteps holds the results of the steps of user flow reports
+	FlowSteps []FlowStepResult `json:"flow_steps,omitempty" bson:"flow_steps,omitempty"`
+	// RunResults holds the metrics of every run of reports with multiple runs
+	RunResults []Run `json:"run_results,omitempty" bson:"run_results,omitempty"`
+	// MedianRun is the index of the run in RunResults that the report shows
+	MedianRun int `json:"median_run" bson:"median_run"`
+	// RunStats maps performance_score and the metrics to their statistics over all runs
+	RunStats map[string]RunStats `json:"run_stats,omitempty" bson:"run_stats,omitempty"`
+	// Environment describes the host and the conditions of the run
+	Environment *Environment `json:"environment,omitempty" bson:"environment,omitempty"`
+	// Unreliable is set when the environment of a run was outside of the configured bounds,
+	// e.g. because the host was loaded. UnreliableReasons explains why.
+	Unreliable        bool     `json:"unreliable" bson:"unreliable"`
+	UnreliableReasons []string `json:"unreliable_reasons,omitempty" bson:"unreliable_reasons,omitempty"`
+	// Status is succeeded, failed when no run produced a usable result, or partial when some
+	// runs of the report failed
+	Status string `json:"status" bson:"status" example:"succeeded"`
+	// RuntimeError explains why the run failed for failed reports
+	RuntimeError *RuntimeError `json:"runtime_error,omitempty" bson:"runtime_error,omitempty"`
+	// RunWarnings holds the warnings lighthouse reported for the run and the failures of other runs
+	RunWarnings []string `json:"run_warnings,omitempty" bson:"run_warnings,omitempty"`
+	// Attempts holds every attempt of the runs of the report, including the retries of
+	// transient failures
+	Attempts []Attempt `json:"attempts,omitempty" bson:"attempts,omitempty"`
+	// Cached is set when the report of an identical earlier request was returned
+	// instead of running lighthouse
+	Cached bool `json:"cached" bson:"-"`
+}
+
+type FlowStepResult struct {
+	Name string `json:"name" bson:"name"`
+	// Possible values are navigation, timespan and snapshot
+	GatherMode string `json:"gather_mode" bson:"gather_mode"`
+	URL        string `json:"url" bson:"url"`
+	// Scores maps the audited categories to their score. Categories that
+	// can't be scored in the gather mode of the step are left out.
+	Scores map[string]float32 `json:"scores" bson:"scores"`
 }
 
 type AuditResult struct {
@@ -163,6 +310,12 @@ func CreateMongoIndexes() {
 	}
 	log.WithField("name", locIndexName).Info("Created index for locations")
 
+	configsIndexName, err := lighthouseConfigs().Indexes().CreateOne(ctx, locationsIndexOpts)
+	if err != nil {
+		log.WithError(err).Error("Error creating mongoDB lighthouse_configs index")
+	}
+	log.WithField("name", configsIndexName).Info("Created index for lighthouse_configs")
+
 	reportsIndex := mongo.IndexModel{
 		Keys: bson.M{
 			"user":       1,
@@ -176,15 +329,35 @@ func CreateMongoIndexes() {
 		log.WithError(err).Error("Error creating mongoDB reports index")
 	}
 	log.WithField("name", reportsIndexName).Info("Created index for reports")
+
+	jobsIndex := mongo.IndexModel{
+		Keys:    bson.M{"created_at": 1},
+		Options: options.Index().SetExpireAfterSeconds(int32(JobRetention.Seconds())),
+	}
+	jobsIndexName, err := jobs().Indexes().CreateOne(ctx, jobsIndex)
+	if err != nil {
+		log.WithError(err).Error("Error creating mongoDB jobs index")
+	}
+	log.WithField("name", jobsIndexName).Info("Created index for jobs")
 }
 
-func GetReports(limit int64, skip int64, query map[string]interface{}) ([]Report, error) {
+// GetReports returns the reports matching query sorted by the field sort,
+// which is prefixed with - to sort in descending order. Reports are sorted by
+// -created_at when sort is empty.
+func GetReports(limit int64, skip int64, query map[string]interface{}, sort string) ([]Report, error) {
 	reports := []Report{}
 	collection := DB.Database(DatabaseName).Collection("reports")
 	c := context.TODO()
 	options := options.Find()
-	options.SetProjection(bson.M{"raw_json": 0, "audit_results": 0, "email": 0, "user": 0})
-	options.SetSort(bson.M{"created_at": -1})
+	options.SetProjection(bson.M{"raw_json": 0, "audit_results": 0, "email": 0, "user": 0, "encrypted_credentials": 0})
+	if sort == "" {
+		sort = "-created_at"
+	}
+	if strings.HasPrefix(sort, "-") {
+		options.SetSort(bson.D{{Key: strings.TrimPrefix(sort, "-"), Value: -1}, {Key: "_id", Value: -1}})
+	} else {
+		options.SetSort(bson.D{{Key: sort, Value: 1}, {Key: "_id", Value: 1}})
+	}
 	options.SetLimit(limit)
 	options.SetSkip(skip)
 	options.SetAllowDiskUse(true)
@@ -224,17 +397,28 @@ func NewReport() *Report {
 func NewReportFromRequest(rr *ReportRequest) *Report {
 	r := NewReport()
 	copier.Copy(&r, rr)
-	if r.Location != "" {
-		l, err := GetLocationByName(r.Location)
-		if err != nil {
-			log.WithError(err).Error("Error getting location by name to set report.LocationDisplay")
-		} else {
-			r.LocationDisplay = l.DisplayName
-		}
-	}
+	// Credentials are only needed to run lighthouse and are never stored
+	// with the report.
+	r.clearCredentials()
+	r.setLocation(r.Location)
 	return r
 }
 
+// setLocation sets the location of the report and its display name.
+func (report *Report) setLocation(name string) {
+	report.Location = name
+	report.LocationDisplay = ""
+	if name == "" {
+		return
+	}
+	l, err := GetLocationByName(name)
+	if err != nil {
+		log.WithError(err).Error("Error getting location by name to set report.LocationDisplay")
+	} else {
+		report.LocationDisplay = l.DisplayName
+	}
+}
+
 func (report *Report) Insert() error {
 	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second)
 	collection := DB.Database(DatabaseName).Collection("reports")
@@ -252,6 +436,9 @@ func (report *Report) Delete() error {
 	if err != nil {
 		return err
 	}
+	if err := report.DeleteArtifacts(); err != nil {
+		log.WithError(err).WithField("report", report.ID).Error("Error deleting report artifacts")
+	}
 	if result.DeletedCount == 1 {
 		return nil
 	} else if result.DeletedCount == 0 {
@@ -266,7 +453,7 @@ func GetReportByObjectIDHex(hex string) (Report, error) {
 	var report Report
 	collection := DB.Database(DatabaseName).Collection("reports")
 	oid, err := primitive.ObjectIDFromHex(hex)
-	options := options.FindOne().SetProjection(bson.M{"email": 0, "user": 0})
+	options := options.FindOne().SetProjection(bson.M{"email": 0, "user": 0, "encrypted_credentials": 0})
 	if err != nil {
 		return report, err
 	}
@@ -420,7 +607,7 @@ func GetAllScheduledReports() ([]ScheduledReport, error) {
 	collection := DB.Database(DatabaseName).Collection("scheduled_reports")
 	c := context.TODO()
 	options := options.Find()
-	options.SetProjection(bson.M{"email": 0, "user": 0})
+	options.SetProjection(bson.M{"email": 0, "user": 0, "encrypted_credentials": 0})
 	cursor, err := collection.Find(c, bson.D{}, options)
 	if err != nil {
 		return nil, err
@@ -439,7 +626,7 @@ func GetScheduledReportByObjectIDHex(hex string) (ScheduledReport, error) {
 		return sr, err
 	}
 	options := options.FindOne()
-	options.SetProjection(bson.M{"email": 0, "user": 0})
+	options.SetProjection(bson.M{"email": 0, "user": 0, "encrypted_credentials": 0})
 	err = collection.FindOne(context.Background(), bson.M{"_id": oid}, options).Decode(&sr)
 	if err != nil {
 		return sr, err
@@ -447,6 +634,19 @@ func GetScheduledReportByObjectIDHex(hex string) (ScheduledReport, error) {
 	return sr, nil
 }
 
+// getScheduledReportToRun returns the scheduled report with the given ID
+// including its user, email and encrypted credentials.
+func getScheduledReportToRun(hex string) (ScheduledReport, error) {
+	var sr ScheduledReport
+	oid, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		return sr, err
+	}
+	collection := DB.Database(DatabaseName).Collection("scheduled_reports")
+	err = collection.FindOne(context.Background(), bson.M{"_id": oid}).Decode(&sr)
+	return sr, err
+}
+
 func GetScheduleReportsDueToRun() ([]ScheduledReport, error) {
 	scheduledReports := []ScheduledReport{}
 	collection := DB.Database(DatabaseName).Collection("scheduled_reports")
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"log"
+	"net/url"
 	"os"
 	"strings"
 	"testing"
@@ -39,6 +40,29 @@ func TestValidateReportInvalidEmail(t *testing.T) {
 	}
 }
 
+func TestValidateFlowStep(t *testing.T) {
+	valid := []FlowStep{
+		{Type: "navigate", URL: "https://www.google.com"},
+		{Type: "click", Selector: "#login"},
+		{Type: "snapshot"},
+	}
+	for _, step := range valid {
+		if err := step.Validate(); err != nil {
+			t.Errorf("Expected step %+v to be valid, but got %v", step, err)
+		}
+	}
+	invalid := []FlowStep{
+		{Type: "navigate"},
+		{Type: "type", Text: "websu"},
+		{Type: "scroll"},
+	}
+	for _, step := range invalid {
+		if err := step.Validate(); err == nil {
+			t.Errorf("Expected an error for step %+v", step)
+		}
+	}
+}
+
 func TestValidateReport404Error(t *testing.T) {
 	r := ReportRequest{}
 	r.URL = "https://samos-it.com/thispagedoesnotexist"
@@ -67,7 +91,7 @@ func TestGetReports(t *testing.T) {
 	r.User = "rob"
 	r.Insert()
 	query := map[string]interface{}{"user": "sam"}
-	reports, err := GetReports(10, 0, query)
+	reports, err := GetReports(10, 0, query, "")
 	if err != nil {
 		t.Error(err.Error())
 	}
@@ -78,7 +102,7 @@ func TestGetReports(t *testing.T) {
 		t.Errorf("Expected report.User to be set to '', but got %v", reports[0].User)
 	}
 
-	reports, err = GetReports(10, 0, nil)
+	reports, err = GetReports(10, 0, nil, "")
 	if err != nil {
 		t.Error(err.Error())
 	}
@@ -321,3 +345,41 @@ func TestLocationUpsert(t *testing.T) {
 	}
 
 }
+
+func TestGetReportsByCategoryScore(t *testing.T) {
+	for _, score := range []float32{0.5, 0.9, 0.7} {
+		r := NewReport()
+		r.URL = "https://www.accessible.com"
+		r.User = "categories"
+		r.Scores = map[string]float32{"accessibility": score}
+		r.Insert()
+	}
+	query, sort, err := reportsQuery(url.Values{"min_score.accessibility": {"0.6"}, "sort": {"-accessibility"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	query["user"] = "categories"
+	reports, err := GetReports(10, 0, query, sort)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 2 {
+		t.Fatalf("len(reports) should be 2, but got %v", len(reports))
+	}
+	if reports[0].Scores["accessibility"] != 0.9 || reports[1].Scores["accessibility"] != 0.7 {
+		t.Errorf("Expected reports sorted by descending accessibility score, but got %v and %v",
+			reports[0].Scores, reports[1].Scores)
+	}
+}
+
+func TestReportsQueryInvalid(t *testing.T) {
+	for _, q := range []url.Values{
+		{"min_score.seo": {"2"}},
+		{"max_score.pwa": {"high"}},
+		{"sort": {"url"}},
+	} {
+		if _, _, err := reportsQuery(q); err == nil {
+			t.Errorf("Expected an error for %v", q)
+		}
+	}
+}
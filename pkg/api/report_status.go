@@ -0,0 +1,116 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/tidwall/gjson"
+	pb "github.com/websu-io/websu/pkg/lighthouse"
+	"google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The possible values of Report.Status
+const (
+	ReportStatusSucceeded = "succeeded"
+	// ReportStatusFailed is set when no run produced a usable result
+	ReportStatusFailed = "failed"
+	// ReportStatusPartial is set when some runs of a report failed
+	ReportStatusPartial = "partial"
+)
+
+var reportStatuses = []string{ReportStatusSucceeded, ReportStatusFailed, ReportStatusPartial}
+
+// RuntimeError describes why a run failed. Code is the lighthouse runtime
+// error code, e.g. NO_FCP or PROTOCOL_TIMEOUT, or the gRPC status code, e.g.
+// DEADLINE_EXCEEDED, when lighthouse-server didn't return a result.
+type RuntimeError struct {
+	Code    string `json:"code" bson:"code" example:"NO_FCP"`
+	Message string `json:"message" bson:"message"`
+}
+
+func (e *RuntimeError) String() string {
+	return e.Code + ": " + e.Message
+}
+
+// parseRuntimeError returns the runtimeError of a lighthouse result or nil
+// when the run succeeded.
+func parseRuntimeError(rawJson []byte) *RuntimeError {
+	results := gjson.GetManyBytes(rawJson, "runtimeError.code", "runtimeError.message")
+	if results[0].String() == "" {
+		return nil
+	}
+	return &RuntimeError{Code: results[0].String(), Message: results[1].String()}
+}
+
+// parseRunWarnings returns the warnings lighthouse reported for the run,
+// e.g. about redirects or a slow host.
+func parseRunWarnings(rawJson []byte) []string {
+	warnings := []string{}
+	for _, w := range gjson.GetBytes(rawJson, "runWarnings").Array() {
+		warnings = append(warnings, w.String())
+	}
+	return warnings
+}
+
+// storeFailure returns true when a run that failed with err is stored as a
+// failed report. Invalid requests and runs that weren't attempted because
+// the server was busy or the client went away aren't stored.
+func storeFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.ResourceExhausted, codes.Canceled:
+		return false
+	}
+	return true
+}
+
+// runOutcome is the result of a single lighthouse run of a report.
+type runOutcome struct {
+	number int
+	// location is where the last attempt of the run ran
+	location string
+	result   *pb.LighthouseResult
+	err      error
+	// runtimeError is set when the run failed
+	runtimeError *RuntimeError
+}
+
+func newRunOutcome(number int, result *pb.LighthouseResult, err error, flow bool) runOutcome {
+	o := runOutcome{number: number, result: result, err: err}
+	if err != nil {
+		st := status.Convert(err)
+		o.runtimeError = &RuntimeError{Code: code.Code(st.Code()).String(), Message: st.Message()}
+		return o
+	}
+	lhr := result.GetStdout()
+	if flow {
+		_, lhr = parseFlowResult(lhr)
+	}
+	o.runtimeError = parseRuntimeError(lhr)
+	return o
+}
+
+// logs returns the log artifact of the run or the logs lighthouse-server
+// attached to the error of a failed run.
+func (o runOutcome) logs() *pb.Artifact {
+	for _, a := range o.result.GetArtifacts() {
+		if a.GetType() == pb.ArtifactType_LOGS {
+			return a
+		}
+	}
+	if logs := pb.ErrorLogs(o.err); logs != "" {
+		return &pb.Artifact{
+			Type:        pb.ArtifactType_LOGS,
+			Name:        "lighthouse.log",
+			ContentType: "text/plain; charset=utf-8",
+			Data:        []byte(logs),
+		}
+	}
+	return nil
+}
+
+// failureWarning describes the failure of a run for the run warnings of
+// reports with multiple runs.
+func (o runOutcome) failureWarning() string {
+	return fmt.Sprintf("run %d failed: %s", o.number, o.runtimeError)
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	pb "github.com/websu-io/websu/pkg/lighthouse"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseRuntimeError(t *testing.T) {
+	lhr := []byte(`{"runtimeError": {"code": "NO_FCP", "message": "The page did not paint any content."},
+		"runWarnings": ["The page loaded too slowly", "The URL has redirected"]}`)
+	rtErr := parseRuntimeError(lhr)
+	if rtErr == nil || rtErr.Code != "NO_FCP" || rtErr.Message != "The page did not paint any content." {
+		t.Errorf("Unexpected runtime error %+v", rtErr)
+	}
+	if warnings := parseRunWarnings(lhr); len(warnings) != 2 || warnings[1] != "The URL has redirected" {
+		t.Errorf("Unexpected run warnings %v", warnings)
+	}
+	if rtErr := parseRuntimeError([]byte(`{"categories": {}}`)); rtErr != nil {
+		t.Errorf("Expected no runtime error for a successful run, but got %+v", rtErr)
+	}
+}
+
+func TestStoreFailure(t *testing.T) {
+	tests := map[error]bool{
+		errors.New("exit status 1"):                          true,
+		status.Error(codes.DeadlineExceeded, "timeout"):      true,
+		status.Error(codes.Unavailable, "connection failed"): true,
+		status.Error(codes.InvalidArgument, "bad request"):   false,
+		status.Error(codes.ResourceExhausted, "busy"):        false,
+		status.Error(codes.Canceled, "canceled"):             false,
+	}
+	for err, expected := range tests {
+		if storeFailure(err) != expected {
+			t.Errorf("Expected storeFailure to be %v for %v", expected, err)
+		}
+	}
+}
+
+func TestNewRunOutcome(t *testing.T) {
+	o := newRunOutcome(1, nil, status.Error(codes.DeadlineExceeded, "lighthouse run was stopped"), false)
+	if o.runtimeError == nil || o.runtimeError.Code != "DEADLINE_EXCEEDED" {
+		t.Errorf("Expected the gRPC code as runtime error code, but got %+v", o.runtimeError)
+	}
+	result := &pb.LighthouseResult{Stdout: []byte(`{"runtimeError": {"code": "PROTOCOL_TIMEOUT", "message": "timeout"}}`)}
+	if o := newRunOutcome(2, result, nil, false); o.runtimeError == nil || o.runtimeError.Code != "PROTOCOL_TIMEOUT" {
+		t.Errorf("Expected the lighthouse runtime error, but got %+v", o.runtimeError)
+	}
+	flow := &pb.LighthouseResult{Stdout: []byte(`{"steps": [{"lhr": {"gatherMode": "navigation",
+		"runtimeError": {"code": "ERRORED_DOCUMENT_REQUEST", "message": "404"}}}]}`)}
+	if o := newRunOutcome(1, flow, nil, true); o.runtimeError == nil || o.runtimeError.Code != "ERRORED_DOCUMENT_REQUEST" {
+		t.Errorf("Expected the runtime error of the flow navigation, but got %+v", o.runtimeError)
+	}
+	if o := newRunOutcome(1, &pb.LighthouseResult{Stdout: []byte(`{}`)}, nil, false); o.runtimeError != nil {
+		t.Errorf("Expected no runtime error, but got %+v", o.runtimeError)
+	}
+}
+
+func TestReportsQueryStatus(t *testing.T) {
+	query, _, err := reportsQuery(url.Values{"status": {"failed"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query["status"] != ReportStatusFailed {
+		t.Errorf("Expected a filter on failed reports, but got %v", query)
+	}
+	query, _, err = reportsQuery(url.Values{"status": {"succeeded"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := query["status"].(map[string]interface{}); !ok {
+		t.Errorf("Expected succeeded to match reports without status, but got %v", query)
+	}
+	if _, _, err := reportsQuery(url.Values{"status": {"broken"}}); err == nil {
+		t.Error("Expected an error for an invalid status")
+	}
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	pb "github.com/websu-io/websu/pkg/lighthouse"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	// RetryMaxAttempts is the maximum number of attempts of a lighthouse run
+	// that fails with a transient error. Runs aren't retried when it's 1.
+	RetryMaxAttempts = 3
+	// RetryBackoff is the delay before the first retry. It doubles with
+	// every further retry.
+	RetryBackoff = 2 * time.Second
+	// RetryOtherLocation makes retries run on another location than the
+	// attempt that failed.
+	RetryOtherLocation = false
+)
+
+// transientCodes are the gRPC status codes of failures that are likely to
+// go away when the run is retried, e.g. because the lighthouse-server of the
+// location was restarted or busy. Plain errors of lighthouse-server, like a
+// lighthouse exit code or an unreadable result, are Unknown and usually fail
+// again, so they aren't retried.
+var transientCodes = map[codes.Code]bool{
+	codes.Unavailable:       true,
+	codes.ResourceExhausted: true,
+	codes.Aborted:           true,
+	codes.DeadlineExceeded:  true,
+}
+
+// transientRuntimeErrors are the lighthouse runtime errors caused by a
+// flaky run rather than by the page, like a 404 of the document.
+var transientRuntimeErrors = map[string]bool{
+	"PROTOCOL_TIMEOUT":        true,
+	"CRI_TIMEOUT":             true,
+	"PAGE_HUNG":               true,
+	"NO_FCP":                  true,
+	"NO_NAVSTART":             true,
+	"NO_TRACING_STARTED":      true,
+	"TRACING_ALREADY_STARTED": true,
+	"NO_DOCUMENT_REQUEST":     true,
+	"FAILED_DOCUMENT_REQUEST": true,
+	"NO_SCREENSHOTS":          true,
+}
+
+// Attempt is a single attempt of a lighthouse run of a report.
+type Attempt struct {
+	// Run is the number of the run the attempt belongs to, starting at 1
+	Run      int    `json:"run" bson:"run"`
+	Location string `json:"location" bson:"location"`
+	// Error is set when the attempt failed
+	Error     *RuntimeError `json:"error,omitempty" bson:"error,omitempty"`
+	Transient bool          `json:"transient" bson:"transient"`
+	StartedAt time.Time     `json:"started_at" bson:"started_at"`
+	// DurationMs is how long the attempt took in milliseconds
+	DurationMs int64 `json:"duration_ms" bson:"duration_ms"`
+	// Request is the redacted request sent to lighthouse-server as JSON. It's
+	// only written to the debug bundle.
+	Request string `json:"-" bson:"request,omitempty"`
+}
+
+// transient returns true when the run failed with an error that is worth
+// retrying.
+func (o runOutcome) transient() bool {
+	if o.err != nil {
+		return transientCodes[status.Code(o.err)]
+	}
+	return o.runtimeError != nil && transientRuntimeErrors[o.runtimeError.Code]
+}
+
+// retryDelay returns how long to wait before the given retry, which starts
+// at 1. Busy lighthouse-servers tell how long to wait in the error.
+func retryDelay(retry int, err error) time.Duration {
+	delay := RetryBackoff << uint(retry-1)
+	if st, ok := status.FromError(err); ok && st.Code() == codes.ResourceExhausted {
+		if d := time.Duration(retryAfterSeconds(st)) * time.Second; d > delay {
+			delay = d
+		}
+	}
+	return delay
+}
+
+type sameLocationKey struct{}
+
+// withSameLocation returns a context whose lighthouse runs are retried on
+// the same location even with RetryOtherLocation, for reports that must be
+// comparable like the reports of an experiment.
+func withSameLocation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sameLocationKey{}, true)
+}
+
+// nextLocation returns the location that a retry runs on. The location
+// following the failed one in alphabetical order is used with
+// RetryOtherLocation.
+func nextLocation(location string) string {
+	if !RetryOtherLocation || len(LighthouseClients) == 0 {
+		return location
+	}
+	names := []string{}
+	for name := range LighthouseClients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name > location {
+			return name
+		}
+	}
+	if names[0] == location {
+		return location
+	}
+	return names[0]
+}
+
+// runWithRetries runs lighthouse and retries transient failures with an
+// exponential backoff until RetryMaxAttempts is reached or ctx is done. It
+// returns the outcome of the last attempt and all attempts.
+func runWithRetries(ctx context.Context, rr *ReportRequest, req *pb.LighthouseRequest, run int) (runOutcome, []Attempt) {
+	location := rr.Location
+	attempts := []Attempt{}
+	for i := 1; ; i++ {
+		start := time.Now()
+		attemptCtx := withRunProgress(ctx, run, i, location)
+		reportProgress(attemptCtx, JobEvent{Type: JobEventAssigned})
+		attemptReq := proto.Clone(req).(*pb.LighthouseRequest)
+		attemptReq.RequestId = fmt.Sprintf("%s-run-%d-attempt-%d", req.GetRequestId(), run, i)
+		result, err := runLighthouse(attemptCtx, lighthouseClient(location), attemptReq)
+		o := newRunOutcome(run, result, err, len(rr.Steps) > 0)
+		o.location = location
+		attempt := Attempt{
+			Run:        run,
+			Location:   location,
+			Error:      o.runtimeError,
+			Transient:  o.transient(),
+			StartedAt:  start,
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+		if sent, err := protojson.Marshal(redactedLighthouseRequest(attemptReq)); err == nil {
+			attempt.Request = string(sent)
+		}
+		attempts = append(attempts, attempt)
+		if o.runtimeError == nil || !attempt.Transient || i >= RetryMaxAttempts || ctx.Err() != nil {
+			return o, attempts
+		}
+		delay := retryDelay(i, err)
+		if ctx.Value(sameLocationKey{}) == nil {
+			location = nextLocation(location)
+		}
+		log.WithFields(log.Fields{
+			"url":      rr.URL,
+			"run":      run,
+			"attempt":  i,
+			"error":    o.runtimeError,
+			"delay":    delay,
+			"location": location,
+		}).Warn("Retrying lighthouse run after transient failure")
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return o, attempts
+		}
+	}
+}
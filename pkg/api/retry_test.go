@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	pb "github.com/websu-io/websu/pkg/lighthouse"
+	"github.com/websu-io/websu/pkg/mocks"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func expectResult(ctrl *gomock.Controller, client *mocks.MockLighthouseServiceClient, stdout string) {
+	stream := mocks.NewMockLighthouseService_RunStreamClient(ctrl)
+	stream.EXPECT().Recv().Return(&pb.RunStreamResponse{
+		Event: &pb.RunStreamResponse_Chunk{Chunk: &pb.ResultChunk{
+			Data: []byte(stdout), TotalSize: int64(len(stdout)), Last: true,
+		}},
+	}, nil)
+	client.EXPECT().RunStream(gomock.Any(), gomock.Any()).Return(stream, nil)
+}
+
+func withRetryPolicy(t *testing.T, attempts int, otherLocation bool) {
+	maxAttempts, backoff, other := RetryMaxAttempts, RetryBackoff, RetryOtherLocation
+	RetryMaxAttempts, RetryBackoff, RetryOtherLocation = attempts, 0, otherLocation
+	t.Cleanup(func() {
+		RetryMaxAttempts, RetryBackoff, RetryOtherLocation = maxAttempts, backoff, other
+	})
+}
+
+func TestRunWithRetriesTransient(t *testing.T) {
+	withRetryPolicy(t, 3, false)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mocks.NewMockLighthouseServiceClient(ctrl)
+	LighthouseClient = client
+	client.EXPECT().RunStream(gomock.Any(), gomock.Any()).Return(nil, status.Error(codes.Unavailable, "connection refused"))
+	expectResult(ctrl, client, `{"runtimeError": {"code": "PROTOCOL_TIMEOUT", "message": "timeout"}}`)
+	expectResult(ctrl, client, `{}`)
+
+	rr := &ReportRequest{URL: "https://www.google.com"}
+	o, attempts := runWithRetries(context.Background(), rr, newLighthouseRequest(rr), 1)
+	if o.runtimeError != nil {
+		t.Errorf("Expected the third attempt to succeed, but got %v", o.runtimeError)
+	}
+	if len(attempts) != 3 || attempts[0].Error.Code != "UNAVAILABLE" || !attempts[1].Transient || attempts[2].Error != nil {
+		t.Errorf("Unexpected attempts %+v", attempts)
+	}
+}
+
+func TestRunWithRetriesPermanent(t *testing.T) {
+	withRetryPolicy(t, 3, false)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mocks.NewMockLighthouseServiceClient(ctrl)
+	LighthouseClient = client
+	expectResult(ctrl, client, `{"runtimeError": {"code": "ERRORED_DOCUMENT_REQUEST", "message": "404"}}`)
+
+	rr := &ReportRequest{URL: "https://www.google.com"}
+	o, attempts := runWithRetries(context.Background(), rr, newLighthouseRequest(rr), 1)
+	if o.runtimeError == nil || len(attempts) != 1 || attempts[0].Transient {
+		t.Errorf("Expected a single attempt for a permanent error, but got %+v", attempts)
+	}
+}
+
+func TestRunWithRetriesUnknown(t *testing.T) {
+	withRetryPolicy(t, 3, false)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mocks.NewMockLighthouseServiceClient(ctrl)
+	LighthouseClient = client
+	client.EXPECT().RunStream(gomock.Any(), gomock.Any()).Return(nil, status.Error(codes.Unknown, "exit status 1"))
+
+	rr := &ReportRequest{URL: "https://www.google.com"}
+	o, attempts := runWithRetries(context.Background(), rr, newLighthouseRequest(rr), 1)
+	if o.err == nil || len(attempts) != 1 || attempts[0].Transient {
+		t.Errorf("Expected a single attempt for an unknown error, but got %+v", attempts)
+	}
+}
+
+func TestRunWithRetriesMaxAttempts(t *testing.T) {
+	withRetryPolicy(t, 2, false)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mocks.NewMockLighthouseServiceClient(ctrl)
+	LighthouseClient = client
+	client.EXPECT().RunStream(gomock.Any(), gomock.Any()).Return(nil, status.Error(codes.Aborted, "aborted")).Times(2)
+
+	rr := &ReportRequest{URL: "https://www.google.com"}
+	o, attempts := runWithRetries(context.Background(), rr, newLighthouseRequest(rr), 2)
+	if o.err == nil || len(attempts) != 2 || attempts[1].Run != 2 {
+		t.Errorf("Expected 2 failed attempts of run 2, but got %+v", attempts)
+	}
+}
+
+func TestRunWithRetriesOtherLocation(t *testing.T) {
+	withRetryPolicy(t, 2, true)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	failing := mocks.NewMockLighthouseServiceClient(ctrl)
+	other := mocks.NewMockLighthouseServiceClient(ctrl)
+	clients := LighthouseClients
+	LighthouseClients = map[string]pb.LighthouseServiceClient{"europe-west1": failing, "us-central1": other}
+	defer func() { LighthouseClients = clients }()
+	failing.EXPECT().RunStream(gomock.Any(), gomock.Any()).Return(nil, status.Error(codes.Unavailable, "down"))
+	expectResult(ctrl, other, `{}`)
+
+	rr := &ReportRequest{URL: "https://www.google.com", Location: "europe-west1"}
+	o, attempts := runWithRetries(context.Background(), rr, newLighthouseRequest(rr), 1)
+	if o.runtimeError != nil || len(attempts) != 2 || attempts[1].Location != "us-central1" {
+		t.Errorf("Expected the retry to succeed on us-central1, but got %+v", attempts)
+	}
+	if o.location != "us-central1" {
+		t.Errorf("Expected the outcome of us-central1, but got %s", o.location)
+	}
+}
+
+func TestRunWithRetriesSameLocation(t *testing.T) {
+	withRetryPolicy(t, 2, true)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	failing := mocks.NewMockLighthouseServiceClient(ctrl)
+	other := mocks.NewMockLighthouseServiceClient(ctrl)
+	clients := LighthouseClients
+	LighthouseClients = map[string]pb.LighthouseServiceClient{"europe-west1": failing, "us-central1": other}
+	defer func() { LighthouseClients = clients }()
+	failing.EXPECT().RunStream(gomock.Any(), gomock.Any()).Return(nil, status.Error(codes.Unavailable, "down"))
+	expectResult(ctrl, failing, `{}`)
+
+	rr := &ReportRequest{URL: "https://www.google.com", Location: "europe-west1"}
+	o, attempts := runWithRetries(withSameLocation(context.Background()), rr, newLighthouseRequest(rr), 1)
+	if o.runtimeError != nil || len(attempts) != 2 || attempts[1].Location != "europe-west1" {
+		t.Errorf("Expected the retry to succeed on europe-west1, but got %+v", attempts)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	backoff := RetryBackoff
+	RetryBackoff = time.Second
+	defer func() { RetryBackoff = backoff }()
+	if d := retryDelay(3, status.Error(codes.Unavailable, "down")); d != 4*time.Second {
+		t.Errorf("Expected an exponential backoff of 4s, but got %v", d)
+	}
+	if d := retryDelay(1, status.Error(codes.ResourceExhausted, "busy")); d != 30*time.Second {
+		t.Errorf("Expected the default retry delay of busy servers, but got %v", d)
+	}
+}
+
+func TestRunWithRetriesRequestID(t *testing.T) {
+	withRetryPolicy(t, 2, false)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mocks.NewMockLighthouseServiceClient(ctrl)
+	LighthouseClient = client
+	requestIDs := []string{}
+	client.EXPECT().RunStream(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, in *pb.LighthouseRequest, opts ...grpc.CallOption) (pb.LighthouseService_RunStreamClient, error) {
+			requestIDs = append(requestIDs, in.GetRequestId())
+			return nil, status.Error(codes.Unavailable, "connection refused")
+		}).Times(2)
+
+	rr := &ReportRequest{URL: "https://www.google.com"}
+	req := newLighthouseRequest(rr)
+	req.RequestId = "5fc9d7a4c3e0f1b2a3d4e5f6"
+	runWithRetries(context.Background(), rr, req, 2)
+	expected := []string{"5fc9d7a4c3e0f1b2a3d4e5f6-run-2-attempt-1", "5fc9d7a4c3e0f1b2a3d4e5f6-run-2-attempt-2"}
+	if len(requestIDs) != 2 || requestIDs[0] != expected[0] || requestIDs[1] != expected[1] {
+		t.Errorf("Expected the request IDs %v, but got %v", expected, requestIDs)
+	}
+}
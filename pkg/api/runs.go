@@ -0,0 +1,119 @@
+package api
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// MaxRuns is the maximum number of lighthouse runs of a report
+const MaxRuns = 5
+
+// Run holds the metrics of one lighthouse run of a report with multiple runs.
+type Run struct {
+	PerformanceScore float32            `json:"performance_score" bson:"performance_score"`
+	Metrics          map[string]float64 `json:"metrics" bson:"metrics"`
+}
+
+// RunStats summarizes the values of a metric over all runs of a report.
+type RunStats struct {
+	Min    float64 `json:"min" bson:"min"`
+	Median float64 `json:"median" bson:"median"`
+	Max    float64 `json:"max" bson:"max"`
+	Stddev float64 `json:"stddev" bson:"stddev"`
+}
+
+// reportTimeout returns the time that creating a report with the requested
+// number of runs may take.
+func reportTimeout(rr *ReportRequest) time.Duration {
+	timeout := 120 * time.Second
+	if rr.Runs > 1 {
+		timeout += time.Duration(rr.Runs-1) * 45 * time.Second
+	}
+	return timeout
+}
+
+func newRun(performanceScore float32, auditResults map[string]AuditResult) Run {
+	run := Run{PerformanceScore: performanceScore, Metrics: map[string]float64{}}
+	for key, ar := range auditResults {
+		run.Metrics[key] = ar.NumericValue
+	}
+	return run
+}
+
+// medianRun returns the index of the median run using the algorithm of
+// lighthouse: the run closest to the median first-contentful-paint and
+// interactive. Runs without these metrics are ignored and the first run is
+// returned when no run has them.
+func medianRun(runs []Run) int {
+	valid := []int{}
+	for i, run := range runs {
+		_, fcp := run.Metrics["first-contentful-paint"]
+		_, tti := run.Metrics["interactive"]
+		if fcp && tti {
+			valid = append(valid, i)
+		}
+	}
+	if len(valid) == 0 {
+		return 0
+	}
+	fcps := []float64{}
+	ttis := []float64{}
+	for _, i := range valid {
+		fcps = append(fcps, runs[i].Metrics["first-contentful-paint"])
+		ttis = append(ttis, runs[i].Metrics["interactive"])
+	}
+	medianFCP := median(fcps)
+	medianTTI := median(ttis)
+	distance := func(i int) float64 {
+		fcp := medianFCP - runs[i].Metrics["first-contentful-paint"]
+		tti := medianTTI - runs[i].Metrics["interactive"]
+		return fcp*fcp + tti*tti
+	}
+	sort.SliceStable(valid, func(a, b int) bool {
+		return distance(valid[a]) < distance(valid[b])
+	})
+	return valid[0]
+}
+
+// newRunStats returns the statistics of performance_score and of every
+// metric over the runs.
+func newRunStats(runs []Run) map[string]RunStats {
+	values := map[string][]float64{}
+	for _, run := range runs {
+		values["performance_score"] = append(values["performance_score"], float64(run.PerformanceScore))
+		for key, v := range run.Metrics {
+			values[key] = append(values[key], v)
+		}
+	}
+	stats := map[string]RunStats{}
+	for key, v := range values {
+		sort.Float64s(v)
+		var sum float64
+		for _, x := range v {
+			sum += x
+		}
+		mean := sum / float64(len(v))
+		var variance float64
+		for _, x := range v {
+			variance += (x - mean) * (x - mean)
+		}
+		stats[key] = RunStats{
+			Min:    v[0],
+			Median: median(v),
+			Max:    v[len(v)-1],
+			Stddev: math.Sqrt(variance / float64(len(v))),
+		}
+	}
+	return stats
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
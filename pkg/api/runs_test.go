@@ -0,0 +1,49 @@
+package api
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func testRun(score float32, fcp float64, tti float64) Run {
+	return Run{PerformanceScore: score, Metrics: map[string]float64{"first-contentful-paint": fcp, "interactive": tti}}
+}
+
+func TestMedianRun(t *testing.T) {
+	runs := []Run{testRun(0.9, 1000, 3000), testRun(0.5, 3000, 9000), testRun(0.7, 1500, 4000)}
+	if got := medianRun(runs); got != 2 {
+		t.Errorf("Expected the median run 2, but got %d", got)
+	}
+	runs = []Run{{Metrics: map[string]float64{}}, testRun(0.5, 3000, 9000)}
+	if got := medianRun(runs); got != 1 {
+		t.Errorf("Expected the only run with metrics to be the median run, but got %d", got)
+	}
+	if got := medianRun([]Run{{}, {}}); got != 0 {
+		t.Errorf("Expected the first run without metrics, but got %d", got)
+	}
+}
+
+func TestNewRunStats(t *testing.T) {
+	stats := newRunStats([]Run{testRun(0.9, 1000, 3000), testRun(0.5, 3000, 9000),
+		testRun(0.7, 1500, 4000), testRun(0.7, 1500, 4000)})
+	fcp := stats["first-contentful-paint"]
+	if fcp.Min != 1000 || fcp.Median != 1500 || fcp.Max != 3000 {
+		t.Errorf("Unexpected first-contentful-paint stats %+v", fcp)
+	}
+	if math.Abs(fcp.Stddev-750) > 0.001 {
+		t.Errorf("Expected a standard deviation of 750, but got %v", fcp.Stddev)
+	}
+	if score := stats["performance_score"]; math.Abs(score.Median-0.7) > 0.001 {
+		t.Errorf("Expected a median performance score of 0.7, but got %+v", score)
+	}
+}
+
+func TestReportTimeout(t *testing.T) {
+	if got := reportTimeout(&ReportRequest{}); got != 120*time.Second {
+		t.Errorf("Expected a timeout of 120s for a single run, but got %v", got)
+	}
+	if got := reportTimeout(&ReportRequest{Runs: MaxRuns}); got != 300*time.Second {
+		t.Errorf("Expected a timeout of 300s for %d runs, but got %v", MaxRuns, got)
+	}
+}
@@ -28,29 +28,52 @@ type ReportRunner interface {
 	RunReport(sr ScheduledReport)
 }
 
-func HTTPRunReport(r ReportRequest) {
-	jsonBody, err := json.Marshal(r)
+// scheduledReportRunRequest returns the URL, body and headers of the
+// websu-api request that runs the scheduled report. With EncryptionKey only
+// the ID and the scheduler token are sent, so the encrypted credentials of
+// the scheduled report never leave mongo. Scheduled reports can't have
+// credentials without EncryptionKey, so their report request is sent to
+// POST /reports then.
+func scheduledReportRunRequest(sr ScheduledReport) (string, []byte, map[string]string, error) {
+	if token := schedulerToken(sr.ID); token != "" {
+		return ApiUrl + "/scheduled-reports/" + sr.ID.Hex() + "/run", nil,
+			map[string]string{schedulerTokenHeader: token}, nil
+	}
+	rr := sr.ReportRequest
+	rr.EncryptedCredentials = ""
+	body, err := json.Marshal(rr)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return ApiUrl + "/reports?cache=false", body, map[string]string{"Content-Type": "application/json"}, nil
+}
+
+func HTTPRunReport(sr ScheduledReport) {
+	url, body, headers, err := scheduledReportRunRequest(sr)
 	if err != nil {
-		log.WithError(err).WithField("r", r).Error("Unable to marshal http RunReport request")
+		log.WithError(err).WithField("ScheduledReport", sr.redacted()).Error("Unable to marshal http RunReport request")
 		return
 	}
-	req, err := http.NewRequest("POST", ApiUrl+"/reports", bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
 	if err != nil {
-		log.WithError(err).WithField("req", req).Error("Unable to create http RunReport request")
+		log.WithError(err).WithField("url", url).Error("Unable to create http RunReport request")
 		return
 	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		log.WithError(err).WithField("req", req).WithField("resp", resp).Error("Unable to execute scheduled RunReport request")
+		log.WithError(err).WithField("url", url).Error("Unable to execute scheduled RunReport request")
 		return
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
-		log.WithField("reportRequest", r).Info("The scheduled report was created successfully")
+		log.WithField("ScheduledReport", sr.redacted()).Info("The scheduled report was created successfully")
 	} else {
 		log.WithFields(log.Fields{
-			"reportRequest": r,
-			"response":      resp,
+			"ScheduledReport": sr.redacted(),
+			"status":          resp.Status,
 		}).Error("Scheduled Run HTTP response code seems like an error")
 	}
 }
@@ -64,7 +87,7 @@ func (gs *GoScheduler) Start() {
 }
 
 func (g GoScheduler) RunReport(sr ScheduledReport) {
-	go HTTPRunReport(sr.ReportRequest)
+	go HTTPRunReport(sr)
 }
 
 func RunScheduledReports(reportRunner ReportRunner) int {
@@ -75,7 +98,7 @@ func RunScheduledReports(reportRunner ReportRunner) int {
 		return 0
 	}
 	for _, sr := range scheduledReports {
-		log.WithField("ScheduledReport", sr).Info("Running scheduled report")
+		log.WithField("ScheduledReport", sr.redacted()).Info("Running scheduled report")
 		reportRunner.RunReport(sr)
 		sr.LastRun = time.Now()
 		sr.Update()
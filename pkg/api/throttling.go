@@ -0,0 +1,124 @@
+package api
+
+import (
+	"fmt"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	pb "github.com/websu-io/websu/pkg/lighthouse"
+)
+
+// Throttling holds the network and CPU throttling of lighthouse. Zero values
+// of custom throttling are taken from the preset.
+type Throttling struct {
+	RttMs                  float64 `json:"rtt_ms,omitempty" bson:"rtt_ms" example:"150"`
+	ThroughputKbps         float64 `json:"throughput_kbps,omitempty" bson:"throughput_kbps" example:"1638.4"`
+	RequestLatencyMs       float64 `json:"request_latency_ms,omitempty" bson:"request_latency_ms" example:"562.5"`
+	DownloadThroughputKbps float64 `json:"download_throughput_kbps,omitempty" bson:"download_throughput_kbps" example:"1474.56"`
+	UploadThroughputKbps   float64 `json:"upload_throughput_kbps,omitempty" bson:"upload_throughput_kbps" example:"675"`
+	CpuSlowdownMultiplier  float64 `json:"cpu_slowdown_multiplier,omitempty" bson:"cpu_slowdown_multiplier" example:"4"`
+}
+
+func (t Throttling) Validate() error {
+	return validation.ValidateStruct(&t,
+		validation.Field(&t.RttMs, validation.Min(0.0), validation.Max(10000.0)),
+		validation.Field(&t.ThroughputKbps, validation.Min(0.0), validation.Max(1000000.0)),
+		validation.Field(&t.RequestLatencyMs, validation.Min(0.0), validation.Max(10000.0)),
+		validation.Field(&t.DownloadThroughputKbps, validation.Min(0.0), validation.Max(1000000.0)),
+		validation.Field(&t.UploadThroughputKbps, validation.Min(0.0), validation.Max(1000000.0)),
+		validation.Field(&t.CpuSlowdownMultiplier, validation.Min(0.0), validation.Max(20.0)),
+	)
+}
+
+// throttlingPresets are the named throttling profiles. The network values of
+// slow-3g and fast-3g match the Chrome DevTools profiles of the same name.
+var throttlingPresets = map[string]Throttling{
+	"slow-3g": {RttMs: 400, ThroughputKbps: 400, RequestLatencyMs: 2000,
+		DownloadThroughputKbps: 400, UploadThroughputKbps: 400, CpuSlowdownMultiplier: 4},
+	"fast-3g": {RttMs: 150, ThroughputKbps: 1638.4, RequestLatencyMs: 562.5,
+		DownloadThroughputKbps: 1474.56, UploadThroughputKbps: 675, CpuSlowdownMultiplier: 4},
+	"4g": {RttMs: 70, ThroughputKbps: 9000, RequestLatencyMs: 262.5,
+		DownloadThroughputKbps: 8100, UploadThroughputKbps: 8100, CpuSlowdownMultiplier: 2},
+	"cable": {RttMs: 40, ThroughputKbps: 10240, CpuSlowdownMultiplier: 1},
+	"none":  {CpuSlowdownMultiplier: 1},
+}
+
+// defaultThrottling is used when neither a preset nor throughput_kbps is set.
+var defaultThrottling = Throttling{ThroughputKbps: 1000, CpuSlowdownMultiplier: 1}
+
+var throttlingMethods = map[string]pb.ThrottlingMethod{
+	"simulate": pb.ThrottlingMethod_SIMULATE,
+	"devtools": pb.ThrottlingMethod_DEVTOOLS,
+	"provided": pb.ThrottlingMethod_PROVIDED,
+}
+
+func throttlingPresetNames() []interface{} {
+	return []interface{}{"slow-3g", "fast-3g", "4g", "cable", "none"}
+}
+
+func throttlingMethodNames() []interface{} {
+	return []interface{}{"simulate", "devtools", "provided"}
+}
+
+// resolveThrottling replaces the preset and custom throttling of the request
+// with the throttling lighthouse runs with, so reports store the values that
+// were used. Custom values take precedence over throughput_kbps, which takes
+// precedence over the preset.
+func (r *ReportRequest) resolveThrottling() error {
+	t := defaultThrottling
+	if r.ThrottlingPreset != "" {
+		preset, ok := throttlingPresets[r.ThrottlingPreset]
+		if !ok {
+			return fmt.Errorf("Unknown throttling preset %q", r.ThrottlingPreset)
+		}
+		t = preset
+		if r.ThrottlingPreset == "none" && r.ThrottlingMethod == "" {
+			r.ThrottlingMethod = "provided"
+		}
+	}
+	if r.ThroughputKbps > 0 {
+		t.ThroughputKbps = float64(r.ThroughputKbps)
+	}
+	if c := r.Throttling; c != nil {
+		for _, v := range []struct {
+			custom float64
+			value  *float64
+		}{
+			{c.RttMs, &t.RttMs},
+			{c.ThroughputKbps, &t.ThroughputKbps},
+			{c.RequestLatencyMs, &t.RequestLatencyMs},
+			{c.DownloadThroughputKbps, &t.DownloadThroughputKbps},
+			{c.UploadThroughputKbps, &t.UploadThroughputKbps},
+			{c.CpuSlowdownMultiplier, &t.CpuSlowdownMultiplier},
+		} {
+			if v.custom > 0 {
+				*v.value = v.custom
+			}
+		}
+	}
+	r.ThroughputKbps = int64(t.ThroughputKbps)
+	r.Throttling = &t
+	if r.ThrottlingMethod == "" {
+		r.ThrottlingMethod = "simulate"
+	}
+	return nil
+}
+
+// lighthouseThrottling returns the throttling of the request for lighthouse.
+// Requests whose throttling wasn't resolved use the default throttling.
+func (r *ReportRequest) lighthouseThrottling() *pb.Throttling {
+	t := r.Throttling
+	if t == nil {
+		t = &defaultThrottling
+		if r.ThroughputKbps > 0 {
+			t = &Throttling{ThroughputKbps: float64(r.ThroughputKbps), CpuSlowdownMultiplier: 1}
+		}
+	}
+	return &pb.Throttling{
+		RttMs:                  t.RttMs,
+		ThroughputKbps:         t.ThroughputKbps,
+		RequestLatencyMs:       t.RequestLatencyMs,
+		DownloadThroughputKbps: t.DownloadThroughputKbps,
+		UploadThroughputKbps:   t.UploadThroughputKbps,
+		CpuSlowdownMultiplier:  t.CpuSlowdownMultiplier,
+	}
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"testing"
+
+	pb "github.com/websu-io/websu/pkg/lighthouse"
+)
+
+func TestResolveThrottling(t *testing.T) {
+	tests := []struct {
+		name     string
+		rr       ReportRequest
+		expected Throttling
+		method   string
+	}{
+		{"default", ReportRequest{}, Throttling{ThroughputKbps: 1000, CpuSlowdownMultiplier: 1}, "simulate"},
+		{"throughput", ReportRequest{ThroughputKbps: 50000},
+			Throttling{ThroughputKbps: 50000, CpuSlowdownMultiplier: 1}, "simulate"},
+		{"preset", ReportRequest{ThrottlingPreset: "fast-3g", ThrottlingMethod: "devtools"},
+			throttlingPresets["fast-3g"], "devtools"},
+		{"none", ReportRequest{ThrottlingPreset: "none"}, Throttling{CpuSlowdownMultiplier: 1}, "provided"},
+		{"custom", ReportRequest{ThrottlingPreset: "cable", Throttling: &Throttling{RttMs: 100, CpuSlowdownMultiplier: 2}},
+			Throttling{RttMs: 100, ThroughputKbps: 10240, CpuSlowdownMultiplier: 2}, "simulate"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rr := test.rr
+			if err := rr.resolveThrottling(); err != nil {
+				t.Fatal(err)
+			}
+			if *rr.Throttling != test.expected {
+				t.Errorf("Expected throttling %+v, but got %+v", test.expected, *rr.Throttling)
+			}
+			if rr.ThrottlingMethod != test.method {
+				t.Errorf("Expected throttling method %s, but got %s", test.method, rr.ThrottlingMethod)
+			}
+		})
+	}
+}
+
+func TestResolveThrottlingUnknownPreset(t *testing.T) {
+	rr := ReportRequest{ThrottlingPreset: "5g"}
+	if err := rr.resolveThrottling(); err == nil {
+		t.Error("Expected an error for an unknown preset")
+	}
+}
+
+func TestNewLighthouseRequestThrottling(t *testing.T) {
+	rr := ReportRequest{URL: "https://www.google.com", ThrottlingPreset: "slow-3g"}
+	if err := rr.resolveThrottling(); err != nil {
+		t.Fatal(err)
+	}
+	req := newLighthouseRequest(&rr)
+	if req.GetThrottlingMethod() != pb.ThrottlingMethod_SIMULATE {
+		t.Errorf("Expected throttling method SIMULATE, but got %v", req.GetThrottlingMethod())
+	}
+	if req.GetThrottling().GetRttMs() != 400 || req.GetThrottling().GetCpuSlowdownMultiplier() != 4 {
+		t.Errorf("Expected the throttling of slow-3g, but got %v", req.GetThrottling())
+	}
+}
+
+func TestValidateThrottling(t *testing.T) {
+	if err := (Throttling{RttMs: 150, CpuSlowdownMultiplier: 4}).Validate(); err != nil {
+		t.Errorf("Expected valid throttling, but got %v", err)
+	}
+	if err := (Throttling{CpuSlowdownMultiplier: -1}).Validate(); err == nil {
+		t.Error("Expected an error for a negative CPU slowdown multiplier")
+	}
+}
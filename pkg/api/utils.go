@@ -7,6 +7,10 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
 )
 
 type malformedRequest struct {
@@ -67,3 +71,15 @@ func decodeJSONBody(w http.ResponseWriter, r *http.Request, dst interface{}) err
 
 	return nil
 }
+
+// retryAfterSeconds returns the retry delay that lighthouse-server attached to
+// a RESOURCE_EXHAUSTED status, rounded up to whole seconds.
+func retryAfterSeconds(st *status.Status) int {
+	for _, detail := range st.Details() {
+		if ri, ok := detail.(*errdetails.RetryInfo); ok {
+			d := ri.GetRetryDelay().AsDuration()
+			return int((d + time.Second - 1) / time.Second)
+		}
+	}
+	return 30
+}
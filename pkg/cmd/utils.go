@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"time"
 )
 
 func GetenvString(key string, defaultVal string) string {
@@ -34,3 +35,25 @@ func GetenvBool(key string, defaultVal bool) bool {
 	}
 	return defaultVal
 }
+
+func GetenvDuration(key string, defaultVal time.Duration) time.Duration {
+	if val, ok := os.LookupEnv(key); ok {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return d
+	}
+	return defaultVal
+}
+
+func GetenvFloat(key string, defaultVal float64) float64 {
+	if val, ok := os.LookupEnv(key); ok {
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return f
+	}
+	return defaultVal
+}
@@ -0,0 +1,197 @@
+package lighthouse
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// reportBasename is the file name, without extension, that lighthouse writes
+// its reports to when artifacts are requested. Lighthouse appends
+// .report.json and .report.html to it and saves the trace as
+// <reportBasename>-0.trace.json.
+const reportBasename = "lighthouse"
+
+// needsOutputDir returns true when the requested artifacts or the result of a
+// user flow are written to files instead of being printed to stdout, or when
+// lighthouse reads a custom config from a file.
+func needsOutputDir(in *LighthouseRequest) bool {
+	if isFlow(in) || hasConfig(in) {
+		return true
+	}
+	for _, a := range in.GetArtifacts() {
+		if a == ArtifactType_HTML_REPORT || a == ArtifactType_TRACE {
+			return true
+		}
+	}
+	return false
+}
+
+func hasArtifact(in *LighthouseRequest, artifact ArtifactType) bool {
+	for _, a := range in.GetArtifacts() {
+		if a == artifact {
+			return true
+		}
+	}
+	return false
+}
+
+// hasRuntimeError returns true when lighthouse saved a result with a
+// runtimeError, which it does before exiting with an error status.
+func hasRuntimeError(stdout []byte, outputDir string) bool {
+	if outputDir != "" {
+		b, err := ioutil.ReadFile(filepath.Join(outputDir, reportBasename+".report.json"))
+		if err != nil {
+			return false
+		}
+		stdout = b
+	}
+	var lhr struct {
+		RuntimeError *struct {
+			Code string `json:"code"`
+		} `json:"runtimeError"`
+	}
+	return json.Unmarshal(stdout, &lhr) == nil && lhr.RuntimeError != nil && lhr.RuntimeError.Code != ""
+}
+
+// readOutputDir reads the lighthouse JSON and the requested file artifacts
+// that lighthouse wrote to dir.
+func readOutputDir(in *LighthouseRequest, dir string) ([]byte, []*Artifact, error) {
+	json, err := ioutil.ReadFile(filepath.Join(dir, reportBasename+".report.json"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error reading lighthouse JSON result: %v", err)
+	}
+	artifacts := []*Artifact{}
+	if hasArtifact(in, ArtifactType_HTML_REPORT) {
+		html, err := ioutil.ReadFile(filepath.Join(dir, reportBasename+".report.html"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error reading lighthouse HTML report: %v", err)
+		}
+		artifacts = append(artifacts, &Artifact{
+			Type:        ArtifactType_HTML_REPORT,
+			Name:        "report.html",
+			ContentType: "text/html; charset=utf-8",
+			Data:        html,
+		})
+	}
+	if hasArtifact(in, ArtifactType_TRACE) {
+		traces, err := filepath.Glob(filepath.Join(dir, "*.trace.json"))
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(traces) == 0 {
+			return nil, nil, fmt.Errorf("Lighthouse didn't save a trace")
+		}
+		for _, path := range traces {
+			trace, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, nil, fmt.Errorf("Error reading lighthouse trace: %v", err)
+			}
+			artifacts = append(artifacts, &Artifact{
+				Type:        ArtifactType_TRACE,
+				Name:        strings.TrimPrefix(filepath.Base(path), reportBasename+"-"),
+				ContentType: "application/json",
+				Data:        trace,
+			})
+		}
+	}
+	return json, artifacts, nil
+}
+
+// screenshotAudits is the subset of the lighthouse JSON that holds the
+// screenshots.
+type screenshotAudits struct {
+	Audits struct {
+		FinalScreenshot struct {
+			Details struct {
+				Timing float64 `json:"timing"`
+				Data   string  `json:"data"`
+			} `json:"details"`
+		} `json:"final-screenshot"`
+		ScreenshotThumbnails struct {
+			Details struct {
+				Items []struct {
+					Timing float64 `json:"timing"`
+					Data   string  `json:"data"`
+				} `json:"items"`
+			} `json:"details"`
+		} `json:"screenshot-thumbnails"`
+	} `json:"audits"`
+}
+
+// extractScreenshots returns the requested screenshots that lighthouse
+// embedded as base64 data URIs in the JSON result.
+func extractScreenshots(in *LighthouseRequest, result []byte) ([]*Artifact, error) {
+	artifacts := []*Artifact{}
+	final := hasArtifact(in, ArtifactType_FINAL_SCREENSHOT)
+	thumbnails := hasArtifact(in, ArtifactType_SCREENSHOT_THUMBNAILS)
+	if !final && !thumbnails {
+		return artifacts, nil
+	}
+	var audits screenshotAudits
+	if err := json.Unmarshal(result, &audits); err != nil {
+		return nil, fmt.Errorf("Error parsing screenshots from lighthouse JSON: %v", err)
+	}
+	if final {
+		details := audits.Audits.FinalScreenshot.Details
+		if details.Data != "" {
+			a, err := dataURIArtifact(ArtifactType_FINAL_SCREENSHOT, "final-screenshot", details.Data)
+			if err != nil {
+				return nil, err
+			}
+			a.Timing = details.Timing
+			artifacts = append(artifacts, a)
+		}
+	}
+	if thumbnails {
+		for i, item := range audits.Audits.ScreenshotThumbnails.Details.Items {
+			a, err := dataURIArtifact(ArtifactType_SCREENSHOT_THUMBNAILS, fmt.Sprintf("thumbnail-%d", i), item.Data)
+			if err != nil {
+				return nil, err
+			}
+			a.Timing = item.Timing
+			artifacts = append(artifacts, a)
+		}
+	}
+	return artifacts, nil
+}
+
+// dataURIArtifact decodes a base64 data URI such as
+// data:image/jpeg;base64,/9j/4AAQ into an artifact.
+func dataURIArtifact(t ArtifactType, name string, uri string) (*Artifact, error) {
+	if !strings.HasPrefix(uri, "data:") {
+		return nil, fmt.Errorf("Screenshot %s isn't a data URI", name)
+	}
+	parts := strings.SplitN(strings.TrimPrefix(uri, "data:"), ",", 2)
+	if len(parts) != 2 || !strings.HasSuffix(parts[0], ";base64") {
+		return nil, fmt.Errorf("Screenshot %s isn't a base64 encoded data URI", name)
+	}
+	data, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("Error decoding screenshot %s: %v", name, err)
+	}
+	contentType := strings.TrimSuffix(parts[0], ";base64")
+	if ext := strings.TrimPrefix(contentType, "image/"); ext != contentType {
+		name += "." + ext
+	}
+	return &Artifact{Type: t, Name: name, ContentType: contentType, Data: data}, nil
+}
+
+// createOutputDir creates a temporary directory in workDir that lighthouse
+// writes its reports to. The directory is writable by everyone because
+// lighthouse doesn't necessarily run as the same user inside docker.
+func createOutputDir(workDir string) (string, error) {
+	dir, err := ioutil.TempDir(workDir, "websu-lighthouse-")
+	if err != nil {
+		return "", err
+	}
+	if err := os.Chmod(dir, 0777); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
@@ -0,0 +1,47 @@
+package lighthouse
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestExtractScreenshots(t *testing.T) {
+	result := []byte(`{"audits": {
+		"final-screenshot": {"details": {"type": "screenshot", "timing": 980, "data": "data:image/jpeg;base64,/9j/4AA="}},
+		"screenshot-thumbnails": {"details": {"type": "filmstrip", "items": [
+			{"timing": 300, "data": "data:image/jpeg;base64,AQID"},
+			{"timing": 600, "data": "data:image/jpeg;base64,BAUG"}
+		]}}
+	}}`)
+	in := &LighthouseRequest{Artifacts: []ArtifactType{ArtifactType_FINAL_SCREENSHOT, ArtifactType_SCREENSHOT_THUMBNAILS}}
+	artifacts, err := extractScreenshots(in, result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(artifacts) != 3 {
+		t.Fatalf("Expected 3 screenshots, but got %d", len(artifacts))
+	}
+	final := artifacts[0]
+	if final.GetName() != "final-screenshot.jpeg" || final.GetContentType() != "image/jpeg" || final.GetTiming() != 980 {
+		t.Errorf("Unexpected final screenshot %v", final)
+	}
+	if !bytes.Equal(final.GetData(), []byte{0xff, 0xd8, 0xff, 0xe0, 0x00}) {
+		t.Errorf("Expected the decoded JPEG data, but got %v", final.GetData())
+	}
+	if thumbnail := artifacts[2]; thumbnail.GetTiming() != 600 || !bytes.Equal(thumbnail.GetData(), []byte{4, 5, 6}) {
+		t.Errorf("Unexpected thumbnail %v", thumbnail)
+	}
+
+	artifacts, err = extractScreenshots(&LighthouseRequest{}, result)
+	if err != nil || len(artifacts) != 0 {
+		t.Errorf("Expected no screenshots when none were requested, but got %v, %v", artifacts, err)
+	}
+}
+
+func TestDataURIArtifactInvalid(t *testing.T) {
+	for _, uri := range []string{"https://example.com/a.jpg", "data:image/jpeg,abc", "data:image/jpeg;base64,!!"} {
+		if _, err := dataURIArtifact(ArtifactType_FINAL_SCREENSHOT, "final-screenshot", uri); err == nil {
+			t.Errorf("Expected an error for data URI %q", uri)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package lighthouse
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ResultCache keeps the results of successful runs for a short time, so
+// identical requests, e.g. from several websu-api instances, don't run
+// lighthouse again.
+type ResultCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]cachedResult
+}
+
+type cachedResult struct {
+	result  *LighthouseResult
+	expires time.Time
+}
+
+// NewResultCache returns a cache that keeps results for ttl.
+func NewResultCache(ttl time.Duration) *ResultCache {
+	return &ResultCache{ttl: ttl, entries: map[string]cachedResult{}}
+}
+
+// get returns the cached result of an identical request or nil.
+func (c *ResultCache) get(in *LighthouseRequest) *LighthouseResult {
+	key := resultCacheKey(in)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		return nil
+	}
+	return e.result
+}
+
+func (c *ResultCache) add(in *LighthouseRequest, result *LighthouseResult) {
+	key := resultCacheKey(in)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for k, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = cachedResult{result: result, expires: now.Add(c.ttl)}
+}
+
+// resultCacheKey hashes the request without the fields that don't change
+// the result.
+func resultCacheKey(in *LighthouseRequest) string {
+	key := proto.Clone(in).(*LighthouseRequest)
+	key.RequestId = ""
+	key.ResultCompression = Compression_NONE
+	key.NoCache = false
+	b, _ := proto.MarshalOptions{Deterministic: true}.Marshal(key)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
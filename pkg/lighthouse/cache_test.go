@@ -0,0 +1,26 @@
+package lighthouse
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRunResultCache(t *testing.T) {
+	runner := &commandRunner{FakeRunner: FakeRunner{Dir: "testdata"}}
+	s := &Server{Runner: runner, Cache: NewResultCache(time.Minute)}
+	requests := []*LighthouseRequest{
+		{Url: "https://www.google.com", RequestId: "1"},
+		{Url: "https://www.google.com", RequestId: "2", ResultCompression: Compression_GZIP},
+		{Url: "https://www.google.com", FormFactor: FormFactor_MOBILE},
+		{Url: "https://www.google.com", RequestId: "3", NoCache: true},
+	}
+	for _, in := range requests {
+		if _, err := s.Run(context.Background(), in); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(runner.commands) != 3 {
+		t.Errorf("Expected 3 lighthouse runs for 2 distinct requests and 1 uncached request, but got %d", len(runner.commands))
+	}
+}
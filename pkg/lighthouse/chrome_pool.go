@@ -0,0 +1,291 @@
+package lighthouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrChromePoolClosed is returned by ChromePool.Acquire after the pool was
+// closed.
+var ErrChromePoolClosed = errors.New("chrome pool is closed")
+
+// ChromePoolConfig configures a ChromePool.
+type ChromePoolConfig struct {
+	// Size is the number of Chrome instances that are kept alive
+	Size int
+	// MaxRuns is the number of runs after which an instance is replaced by a
+	// fresh one. Instances are only replaced when they crash when it's zero.
+	MaxRuns int
+	// ChromePath is the Chrome executable
+	ChromePath string
+	// StartTimeout is how long a started Chrome may take to accept DevTools
+	// connections.
+	StartTimeout time.Duration
+}
+
+// ChromePool keeps headless Chrome instances alive that lighthouse connects
+// to with --port, so runs don't pay for starting Chrome. Lighthouse opens a
+// new tab in the default browser context for every run and resets the
+// storage of the audited origin, user flows use their own incognito context.
+// Each instance has its own profile, which is thrown away when the instance
+// is replaced after MaxRuns runs, a crash or a run that was discarded.
+type ChromePool struct {
+	config ChromePoolConfig
+	idle   chan *chromeInstance
+	// start starts a new instance and is replaced in tests
+	start func() (*chromeInstance, error)
+
+	mu        sync.Mutex
+	instances map[*chromeInstance]bool
+	busy      int
+	runs      int64
+	restarts  int64
+	crashes   int64
+	closed    bool
+}
+
+type chromeInstance struct {
+	port   int
+	runs   int
+	exited chan struct{}
+	stop   func()
+}
+
+func (c *chromeInstance) crashed() bool {
+	select {
+	case <-c.exited:
+		return true
+	default:
+		return false
+	}
+}
+
+// NewChromePool starts the Chrome instances of the pool.
+func NewChromePool(config ChromePoolConfig) (*ChromePool, error) {
+	p := newChromePool(config)
+	p.start = p.startChrome
+	if err := p.fill(); err != nil {
+		p.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+func newChromePool(config ChromePoolConfig) *ChromePool {
+	if config.StartTimeout == 0 {
+		config.StartTimeout = 30 * time.Second
+	}
+	return &ChromePool{
+		config:    config,
+		idle:      make(chan *chromeInstance, config.Size),
+		instances: map[*chromeInstance]bool{},
+	}
+}
+
+func (p *ChromePool) fill() error {
+	if p.config.Size < 1 {
+		return fmt.Errorf("The size of the chrome pool must be at least 1")
+	}
+	for i := 0; i < p.config.Size; i++ {
+		c, err := p.start()
+		if err != nil {
+			return err
+		}
+		p.add(c)
+	}
+	return nil
+}
+
+// add makes a started instance available or stops it when the pool was
+// closed in the meantime.
+func (p *ChromePool) add(c *chromeInstance) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		c.stop()
+		return
+	}
+	p.instances[c] = true
+	p.idle <- c
+}
+
+// Acquire blocks until a Chrome instance is available or ctx is done. The
+// instance must be returned with Release after the run.
+func (p *ChromePool) Acquire(ctx context.Context) (*chromeInstance, error) {
+	for {
+		select {
+		case c := <-p.idle:
+			p.mu.Lock()
+			if p.closed {
+				p.mu.Unlock()
+				return nil, ErrChromePoolClosed
+			}
+			if c.crashed() {
+				p.crashes++
+				p.mu.Unlock()
+				p.replace(c)
+				continue
+			}
+			p.busy++
+			p.mu.Unlock()
+			return c, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Release returns an instance after a run. The instance is replaced when it
+// crashed, stopped responding after a failed run or reached MaxRuns. discard
+// replaces the instance as well, for runs whose cookies and cache must not be
+// seen by later runs.
+func (p *ChromePool) Release(c *chromeInstance, runErr error, discard bool) {
+	c.runs++
+	crashed := c.crashed() || (runErr != nil && !chromeHealthy(c.port))
+	p.mu.Lock()
+	p.busy--
+	p.runs++
+	if crashed {
+		p.crashes++
+	}
+	closed := p.closed
+	p.mu.Unlock()
+	switch {
+	case closed:
+		c.stop()
+	case crashed:
+		log.Printf("Replacing chrome on port %d that crashed after %d runs", c.port, c.runs)
+		p.replace(c)
+	case discard, p.config.MaxRuns > 0 && c.runs >= p.config.MaxRuns:
+		p.replace(c)
+	default:
+		p.idle <- c
+	}
+}
+
+// replace stops the instance and starts a new one in the background. Starts
+// are retried until they succeed or the pool is closed.
+func (p *ChromePool) replace(c *chromeInstance) {
+	p.mu.Lock()
+	delete(p.instances, c)
+	p.restarts++
+	p.mu.Unlock()
+	go func() {
+		c.stop()
+		for {
+			p.mu.Lock()
+			closed := p.closed
+			p.mu.Unlock()
+			if closed {
+				return
+			}
+			n, err := p.start()
+			if err == nil {
+				p.add(n)
+				return
+			}
+			log.Printf("Error starting chrome for the pool: %v", err)
+			time.Sleep(time.Second)
+		}
+	}()
+}
+
+// Status returns the statistics of the pool.
+func (p *ChromePool) Status() *ChromePoolStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return &ChromePoolStatus{
+		Size:     int32(p.config.Size),
+		Idle:     int32(len(p.idle)),
+		Busy:     int32(p.busy),
+		Runs:     p.runs,
+		Restarts: p.restarts,
+		Crashes:  p.crashes,
+	}
+}
+
+// Close stops all Chrome instances of the pool.
+func (p *ChromePool) Close() {
+	p.mu.Lock()
+	p.closed = true
+	instances := p.instances
+	p.instances = map[*chromeInstance]bool{}
+	p.mu.Unlock()
+	for c := range instances {
+		c.stop()
+	}
+}
+
+// startChrome starts headless Chrome with a fresh profile and waits until it
+// accepts DevTools connections.
+func (p *ChromePool) startChrome() (*chromeInstance, error) {
+	port, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := ioutil.TempDir("", "websu-chrome-")
+	if err != nil {
+		return nil, err
+	}
+	args := append(append([]string{}, defaultChromeflags...),
+		"--remote-debugging-port="+strconv.Itoa(port), "--user-data-dir="+dir, "about:blank")
+	cmd := exec.Command(p.config.ChromePath, args...)
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+	c := &chromeInstance{port: port, exited: exited}
+	c.stop = func() {
+		if err := killProcessGroup(cmd); err != nil && !c.crashed() {
+			log.Printf("Error killing chrome on port %d: %v", port, err)
+		}
+		<-exited
+		os.RemoveAll(dir)
+	}
+	deadline := time.Now().Add(p.config.StartTimeout)
+	for !chromeHealthy(port) {
+		if c.crashed() || time.Now().After(deadline) {
+			c.stop()
+			return nil, fmt.Errorf("Chrome didn't accept DevTools connections on port %d", port)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	log.Printf("Started chrome for the pool on port %d", port)
+	return c, nil
+}
+
+// chromeHealthy returns true when Chrome answers DevTools requests on port.
+func chromeHealthy(port int) bool {
+	client := http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get(fmt.Sprintf("http://127.0.0.1:%d/json/version", port))
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
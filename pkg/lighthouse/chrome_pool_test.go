@@ -0,0 +1,169 @@
+package lighthouse
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeChromePool returns a pool whose instances are channels instead of
+// Chrome processes. Closing the exited channel of an instance simulates a
+// crash.
+func fakeChromePool(t *testing.T, config ChromePoolConfig) (*ChromePool, func() []*chromeInstance) {
+	var mu sync.Mutex
+	started := []*chromeInstance{}
+	p := newChromePool(config)
+	p.start = func() (*chromeInstance, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		c := &chromeInstance{port: 1, exited: make(chan struct{})}
+		c.stop = func() {}
+		started = append(started, c)
+		return c, nil
+	}
+	if err := p.fill(); err != nil {
+		t.Fatal(err)
+	}
+	return p, func() []*chromeInstance {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*chromeInstance{}, started...)
+	}
+}
+
+func waitForIdle(t *testing.T, p *ChromePool, idle int32) {
+	deadline := time.Now().Add(5 * time.Second)
+	for p.Status().GetIdle() != idle {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d idle instances, but got %v", idle, p.Status())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestChromePoolMaxRuns(t *testing.T) {
+	p, started := fakeChromePool(t, ChromePoolConfig{Size: 1, MaxRuns: 2})
+	defer p.Close()
+	first, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st := p.Status(); st.GetBusy() != 1 || st.GetIdle() != 0 {
+		t.Errorf("Expected 1 busy and 0 idle instances, but got %v", st)
+	}
+	p.Release(first, nil, false)
+	c, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != first {
+		t.Error("Expected the instance to be reused before reaching MaxRuns")
+	}
+	p.Release(c, nil, false)
+	waitForIdle(t, p, 1)
+	if c, _ = p.Acquire(context.Background()); c == first {
+		t.Error("Expected the instance to be replaced after reaching MaxRuns")
+	}
+	p.Release(c, nil, false)
+	st := p.Status()
+	if st.GetRuns() != 3 || st.GetRestarts() != 1 || st.GetCrashes() != 0 || len(started()) != 2 {
+		t.Errorf("Unexpected status %v after starting %d instances", st, len(started()))
+	}
+}
+
+func TestChromePoolCrash(t *testing.T) {
+	p, started := fakeChromePool(t, ChromePoolConfig{Size: 1})
+	defer p.Close()
+	// An idle instance that crashed is replaced when acquired
+	close(started()[0].exited)
+	c, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.crashed() {
+		t.Error("Expected a replacement for the crashed instance")
+	}
+	// An instance that crashed during a run is replaced when released
+	close(c.exited)
+	p.Release(c, nil, false)
+	waitForIdle(t, p, 1)
+	if st := p.Status(); st.GetCrashes() != 2 || st.GetRestarts() != 2 || len(started()) != 3 {
+		t.Errorf("Unexpected status %v after starting %d instances", st, len(started()))
+	}
+}
+
+func TestChromePoolDiscard(t *testing.T) {
+	p, started := fakeChromePool(t, ChromePoolConfig{Size: 1})
+	defer p.Close()
+	first, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Release(first, nil, true)
+	waitForIdle(t, p, 1)
+	if c, _ := p.Acquire(context.Background()); c == first {
+		t.Error("Expected a discarded instance to be replaced")
+	} else {
+		p.Release(c, nil, false)
+	}
+	if st := p.Status(); st.GetRestarts() != 1 || st.GetCrashes() != 0 || len(started()) != 2 {
+		t.Errorf("Unexpected status %v after starting %d instances", st, len(started()))
+	}
+}
+
+func TestChromePoolAcquireCanceled(t *testing.T) {
+	p, _ := fakeChromePool(t, ChromePoolConfig{Size: 1})
+	defer p.Close()
+	c, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Release(c, nil, false)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Acquire(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded while all instances are busy, but got %v", err)
+	}
+}
+
+// commandRunner records the commands it runs and returns a canned result.
+type commandRunner struct {
+	FakeRunner
+	commands [][]string
+	ids      []string
+}
+
+func (r *commandRunner) Run(ctx context.Context, req RunRequest) ([]byte, error) {
+	r.commands = append(r.commands, req.Command)
+	r.ids = append(r.ids, req.ID)
+	return r.FakeRunner.Run(ctx, req)
+}
+
+func TestRunChromePool(t *testing.T) {
+	p, _ := fakeChromePool(t, ChromePoolConfig{Size: 1})
+	defer p.Close()
+	runner := &commandRunner{FakeRunner: FakeRunner{Dir: "testdata"}}
+	s := &Server{Runner: runner, ChromePool: p}
+	if _, err := s.Run(context.Background(), &LighthouseRequest{Url: "https://www.google.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if command := strings.Join(runner.commands[0], " "); !strings.Contains(command, "--port=1") {
+		t.Errorf("Expected lighthouse to connect to the pooled Chrome, but got %s", command)
+	}
+	_, err := s.Run(context.Background(), &LighthouseRequest{Url: "https://www.google.com", Chromeflags: []string{"--disable-gpu"}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected code InvalidArgument for chrome flags, but got %v", err)
+	}
+	resp, err := s.Status(context.Background(), &StatusRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pool := resp.GetChromePool(); pool.GetSize() != 1 || pool.GetIdle() != 1 || pool.GetRuns() != 1 {
+		t.Errorf("Unexpected chrome pool status %v", pool)
+	}
+}
@@ -0,0 +1,93 @@
+package lighthouse
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+)
+
+// configFile is the file in the output directory that the custom lighthouse
+// config of a request is written to.
+const configFile = "config.json"
+
+// moduleNameRegexp matches the names of the core audits and gatherers of
+// lighthouse, e.g. "metrics/first-contentful-paint", but no file paths.
+var moduleNameRegexp = regexp.MustCompile(`^[a-z0-9-]+(/[a-z0-9-]+)*$`)
+
+func hasConfig(in *LighthouseRequest) bool {
+	return in.GetConfigJson() != ""
+}
+
+// customConfig parses the custom lighthouse config of the request.
+func customConfig(in *LighthouseRequest) (map[string]interface{}, error) {
+	config := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(in.GetConfigJson()), &config); err != nil {
+		return nil, fmt.Errorf("The lighthouse config must be a JSON object: %v", err)
+	}
+	return config, nil
+}
+
+// checkConfig returns an error when the custom config would make lighthouse
+// load code or files from the host: audits and gatherers given by path,
+// plugins that aren't in plugins and the settings that read or write
+// artifacts.
+func checkConfig(config map[string]interface{}, plugins []string) error {
+	if extends, ok := config["extends"]; ok && extends != "lighthouse:default" {
+		return fmt.Errorf("The lighthouse config can only extend lighthouse:default")
+	}
+	if v, ok := config["plugins"]; ok {
+		list, _ := v.([]interface{})
+		for _, plugin := range list {
+			name, _ := plugin.(string)
+			if !Contains(plugins, name) {
+				return fmt.Errorf("The lighthouse plugin %v is not allowed", plugin)
+			}
+		}
+		if list == nil {
+			return fmt.Errorf("The plugins of the lighthouse config must be a list")
+		}
+	}
+	modules := configList(config["audits"])
+	for _, pass := range configList(config["passes"]) {
+		if p, ok := pass.(map[string]interface{}); ok {
+			modules = append(modules, configList(p["gatherers"])...)
+		}
+	}
+	for _, artifact := range configList(config["artifacts"]) {
+		if a, ok := artifact.(map[string]interface{}); ok {
+			modules = append(modules, a["gatherer"])
+		}
+	}
+	for _, module := range modules {
+		if m, ok := module.(map[string]interface{}); ok {
+			module = m["path"]
+		}
+		if name, ok := module.(string); !ok || !moduleNameRegexp.MatchString(name) {
+			return fmt.Errorf("The lighthouse config can only use audits and gatherers by name, not %v", module)
+		}
+	}
+	if settings, ok := config["settings"].(map[string]interface{}); ok {
+		for _, name := range []string{"gatherMode", "auditMode"} {
+			if _, ok := settings[name]; ok {
+				return fmt.Errorf("The lighthouse config setting %s is not allowed", name)
+			}
+		}
+	}
+	return nil
+}
+
+func configList(v interface{}) []interface{} {
+	list, _ := v.([]interface{})
+	return list
+}
+
+// writeConfig writes the custom lighthouse config of the request to
+// outputDir, so it can be passed to lighthouse with --config-path.
+func writeConfig(in *LighthouseRequest, outputDir string) error {
+	if _, err := customConfig(in); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(outputDir, configFile), []byte(in.GetConfigJson()), 0644)
+}
@@ -0,0 +1,99 @@
+package lighthouse
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const testConfig = `{"extends": "lighthouse:default", "plugins": ["lighthouse-plugin-field-performance"],
+	"settings": {"maxWaitForLoad": 60000, "onlyCategories": ["seo"]}}`
+
+func TestLighthouseCommandConfig(t *testing.T) {
+	command, err := lighthouseCommand(&LighthouseRequest{Url: "https://www.google.com", ConfigJson: testConfig}, "/tmp/out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Contains(command, "--config-path=/tmp/out/config.json") {
+		t.Errorf("Expected flag --config-path=/tmp/out/config.json in command %v", command)
+	}
+}
+
+func TestRunConfig(t *testing.T) {
+	policy := DefaultPolicy().Merge(&Policy{AllowedConfigPlugins: []string{"lighthouse-plugin-field-performance"}})
+	s := &Server{Runner: &FakeRunner{Dir: "testdata"}, Policy: policy}
+	result, err := s.Run(context.Background(), &LighthouseRequest{Url: "https://www.google.com", ConfigJson: testConfig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.GetStdout()) == 0 {
+		t.Error("Expected a lighthouse result")
+	}
+	_, err = s.Run(context.Background(), &LighthouseRequest{Url: "https://www.google.com", ConfigJson: "[1, 2]"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected code InvalidArgument for a config that isn't a JSON object, but got %v", err)
+	}
+	s.Policy = DefaultPolicy()
+	_, err = s.Run(context.Background(), &LighthouseRequest{Url: "https://www.google.com", ConfigJson: testConfig})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected code InvalidArgument for a plugin that isn't allowed, but got %v", err)
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	plugins := []string{"lighthouse-plugin-field-performance"}
+	denied := []string{
+		`{"extends": "/etc/lighthouse.js"}`,
+		`{"plugins": ["lighthouse-plugin-other"]}`,
+		`{"plugins": "lighthouse-plugin-field-performance"}`,
+		`{"audits": ["/tmp/evil-audit.js"]}`,
+		`{"audits": [{"path": "../../evil-audit"}]}`,
+		`{"passes": [{"passName": "defaultPass", "gatherers": ["./evil-gatherer"]}]}`,
+		`{"artifacts": [{"id": "Evil", "gatherer": {"path": "/tmp/evil.js"}}]}`,
+		`{"settings": {"auditMode": "/etc"}}`,
+	}
+	for _, c := range denied {
+		config, err := customConfig(&LighthouseRequest{ConfigJson: c})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := checkConfig(config, plugins); err == nil {
+			t.Errorf("Expected config %s to be denied", c)
+		}
+	}
+	allowed := `{"extends": "lighthouse:default", "plugins": ["lighthouse-plugin-field-performance"],
+		"audits": ["metrics/first-contentful-paint", {"path": "byte-efficiency/uses-long-cache-ttl", "options": {}}],
+		"passes": [{"passName": "defaultPass", "gatherers": ["seo/font-size"]}],
+		"settings": {"onlyCategories": ["seo"]}}`
+	config, err := customConfig(&LighthouseRequest{ConfigJson: allowed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkConfig(config, plugins); err != nil {
+		t.Errorf("Expected the config to be allowed, but got %v", err)
+	}
+}
+
+func TestNewFlowInputConfig(t *testing.T) {
+	input, err := newFlowInput(&LighthouseRequest{
+		Url:        "https://www.google.com",
+		FlowSteps:  []*FlowStep{{Type: FlowStep_SNAPSHOT}},
+		Categories: []string{"performance"},
+		ConfigJson: testConfig,
+	}, "/tmp/out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := input.Config["plugins"]; !ok {
+		t.Errorf("Expected the plugins of the custom config, but got %v", input.Config)
+	}
+	settings := input.Config["settings"].(map[string]interface{})
+	if settings["maxWaitForLoad"] != float64(60000) {
+		t.Errorf("Expected the settings of the custom config, but got %v", settings)
+	}
+	if categories := settings["onlyCategories"].([]string); len(categories) != 1 || categories[0] != "performance" {
+		t.Errorf("Expected the categories of the request to override the config, but got %v", settings["onlyCategories"])
+	}
+}
@@ -0,0 +1,60 @@
+package lighthouse
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// loadAveragePath is read for the load average of the host. Containers see
+// the load average of the host as well.
+var loadAveragePath = "/proc/loadavg"
+
+var chromeVersionRegexp = regexp.MustCompile(`Chrome/([0-9.]+)`)
+
+// loadAverage returns the 1 minute load average of the host or 0 when it
+// can't be read, e.g. on systems without /proc.
+func loadAverage() float64 {
+	b, err := ioutil.ReadFile(loadAveragePath)
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(b))
+	if len(fields) == 0 {
+		return 0
+	}
+	load, _ := strconv.ParseFloat(fields[0], 64)
+	return load
+}
+
+type lhrEnvironment struct {
+	Environment struct {
+		HostUserAgent  string  `json:"hostUserAgent"`
+		BenchmarkIndex float64 `json:"benchmarkIndex"`
+	} `json:"environment"`
+}
+
+// addResultEnvironment sets the Chrome version and benchmark index of env
+// from the lighthouse JSON or, for user flows, from the first step of the
+// flow result.
+func addResultEnvironment(env *Environment, result []byte) {
+	var r struct {
+		lhrEnvironment
+		Steps []struct {
+			LHR lhrEnvironment `json:"lhr"`
+		} `json:"steps"`
+	}
+	if err := json.Unmarshal(result, &r); err != nil {
+		return
+	}
+	lhr := r.lhrEnvironment
+	if len(r.Steps) > 0 {
+		lhr = r.Steps[0].LHR
+	}
+	if m := chromeVersionRegexp.FindStringSubmatch(lhr.Environment.HostUserAgent); m != nil {
+		env.ChromeVersion = m[1]
+	}
+	env.BenchmarkIndex = lhr.Environment.BenchmarkIndex
+}
@@ -0,0 +1,58 @@
+package lighthouse
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadAverage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "websu-loadavg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "loadavg")
+	if err := ioutil.WriteFile(path, []byte("1.50 0.75 0.25 2/345 6789\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer func(p string) { loadAveragePath = p }(loadAveragePath)
+	loadAveragePath = path
+	if got := loadAverage(); got != 1.5 {
+		t.Errorf("Expected load average 1.5, but got %v", got)
+	}
+	loadAveragePath = filepath.Join(dir, "missing")
+	if got := loadAverage(); got != 0 {
+		t.Errorf("Expected load average 0 when it can't be read, but got %v", got)
+	}
+}
+
+func TestAddResultEnvironment(t *testing.T) {
+	env := &Environment{}
+	addResultEnvironment(env, []byte(`{"steps": [{"lhr": {"environment": {
+		"hostUserAgent": "Mozilla/5.0 HeadlessChrome/98.0.4758.102 Safari/537.36", "benchmarkIndex": 1520.5}}}]}`))
+	if env.GetChromeVersion() != "98.0.4758.102" || env.GetBenchmarkIndex() != 1520.5 {
+		t.Errorf("Unexpected environment of flow result %v", env)
+	}
+}
+
+func TestRunStreamEnvironment(t *testing.T) {
+	client := startTestServer(t, &Server{Runner: &FakeRunner{Dir: "testdata"}})
+	stream, err := client.RunStream(context.Background(), &LighthouseRequest{Url: "https://www.google.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReceiveResult(stream, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := got.GetEnvironment()
+	if env.GetCpuCount() < 1 || env.GetConcurrentRuns() != 1 {
+		t.Errorf("Expected the CPU count and a single concurrent run, but got %v", env)
+	}
+	if env.GetChromeVersion() == "" || env.GetBenchmarkIndex() == 0 {
+		t.Errorf("Expected the Chrome version and benchmark index of the result, but got %v", env)
+	}
+}
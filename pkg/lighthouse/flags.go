@@ -0,0 +1,220 @@
+package lighthouse
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Categories are the lighthouse categories that can be requested.
+var Categories = []string{"performance", "accessibility", "best-practices", "seo", "pwa"}
+
+var defaultCategories = []string{"best-practices", "performance", "seo"}
+
+var defaultChromeflags = []string{"--no-sandbox", "--headless", "--disable-dev-shm-usage",
+	"--hide-scrollbars", "--disable-features=TranslateUI", "--disable-extensions",
+	"--disable-component-extensions-with-background-pages", "--disable-background-networking", "--disable-sync",
+	"--metrics-recording-only", "--disable-default-apps", "--mute-audio", "--no-default-browser-check",
+	"--no-first-run", "--disable-backgrounding-occluded-windows", "--disable-renderer-backgrounding",
+	"--disable-background-timer-throttling", "--force-fieldtrials=*BackgroundTracing/default/",
+	"--use-gl=swiftshader", "--disable-software-rasterizer"}
+
+// formFactorSettings are the screen emulation and user agent that lighthouse
+// used for each form factor before they had to be set separately.
+var formFactorSettings = map[FormFactor]struct {
+	screen    *ScreenEmulation
+	userAgent string
+}{
+	FormFactor_DESKTOP: {
+		screen:    &ScreenEmulation{Mobile: false, Width: 1350, Height: 940, DeviceScaleFactor: 1},
+		userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/98.0.4695.0 Safari/537.36 Chrome-Lighthouse",
+	},
+	FormFactor_MOBILE: {
+		screen:    &ScreenEmulation{Mobile: true, Width: 360, Height: 640, DeviceScaleFactor: 2.625},
+		userAgent: "Mozilla/5.0 (Linux; Android 7.0; Moto G (4)) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/98.0.4695.0 Mobile Safari/537.36 Chrome-Lighthouse",
+	},
+}
+
+var (
+	// LocaleRegexp matches the locales lighthouse accepts
+	LocaleRegexp = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+	// HeaderNameRegexp matches valid HTTP header names
+	HeaderNameRegexp = regexp.MustCompile("^[!#$%&'*+\\-.^_`|~0-9a-zA-Z]+$")
+)
+
+// lighthouseCommand returns the lighthouse command line for the request. The
+// typed fields of the request are validated and mapped to lighthouse flags.
+// Lighthouse prints the JSON result to stdout when outputDir is empty and
+// otherwise writes its reports and assets to outputDir.
+func lighthouseCommand(in *LighthouseRequest, outputDir string) ([]string, error) {
+	chromeflags := append(append([]string{}, defaultChromeflags...), in.GetChromeflags()...)
+	command := []string{"lighthouse", in.GetUrl(),
+		fmt.Sprintf("--chrome-flags=\"%s\"", strings.Join(chromeflags, " "))}
+	if outputDir == "" {
+		command = append(command, "--output=json", "--output-path=stdout")
+	} else {
+		// Lighthouse only appends .report.<format> to the output path when
+		// there are multiple outputs, so html is always included.
+		command = append(command, "--output=json", "--output=html",
+			"--output-path="+filepath.Join(outputDir, reportBasename))
+		if hasArtifact(in, ArtifactType_TRACE) {
+			command = append(command, "--save-assets")
+		}
+		if hasConfig(in) {
+			command = append(command, "--config-path="+filepath.Join(outputDir, configFile))
+		}
+	}
+	skipAudits := []string{"apple-touch-icon"}
+	if !hasArtifact(in, ArtifactType_FINAL_SCREENSHOT) {
+		skipAudits = append(skipAudits, "final-screenshot")
+	}
+	if !hasArtifact(in, ArtifactType_SCREENSHOT_THUMBNAILS) {
+		skipAudits = append(skipAudits, "screenshot-thumbnails")
+	}
+	command = append(command, "--disable-dev-shm-usage", "--skip-audits="+strings.Join(skipAudits, ","))
+	if in.GetVerbose() {
+		command = append(command, "--verbose")
+	}
+
+	// Raw options could override the flags of the typed fields
+	if len(in.GetOptions()) > 0 && hasTypedFields(in) {
+		return nil, fmt.Errorf("Options can't be combined with the typed fields of the request")
+	}
+	flags, err := typedFlags(in)
+	if err != nil {
+		return nil, err
+	}
+	command = append(command, flags...)
+	return append(command, in.GetOptions()...), nil
+}
+
+// hasTypedFields returns true when the request sets one of the typed fields
+// that are mapped to lighthouse flags.
+func hasTypedFields(in *LighthouseRequest) bool {
+	return in.GetFormFactor() != FormFactor_FORM_FACTOR_UNSPECIFIED || in.GetScreenEmulation() != nil ||
+		in.GetUserAgent() != "" || in.GetThrottlingMethod() != ThrottlingMethod_THROTTLING_METHOD_UNSPECIFIED ||
+		in.GetThrottling() != nil || in.GetLocale() != "" || len(in.GetCategories()) > 0 ||
+		len(in.GetExtraHeaders()) > 0 || len(in.GetBlockedUrlPatterns()) > 0
+}
+
+func typedFlags(in *LighthouseRequest) ([]string, error) {
+	flags := []string{}
+
+	for _, a := range in.GetArtifacts() {
+		if _, ok := ArtifactType_name[int32(a)]; !ok || a == ArtifactType_ARTIFACT_TYPE_UNSPECIFIED {
+			return nil, fmt.Errorf("Unknown artifact type %v", a)
+		}
+	}
+
+	categories := in.GetCategories()
+	if len(categories) == 0 {
+		categories = defaultCategories
+	}
+	for _, c := range categories {
+		if !Contains(Categories, c) {
+			return nil, fmt.Errorf("Unknown category %q. Possible values are: %s", c, strings.Join(Categories, ", "))
+		}
+	}
+	flags = append(flags, "--only-categories="+strings.Join(categories, ","))
+
+	screen := in.GetScreenEmulation()
+	userAgent := in.GetUserAgent()
+	if formFactor := in.GetFormFactor(); formFactor != FormFactor_FORM_FACTOR_UNSPECIFIED {
+		settings, ok := formFactorSettings[formFactor]
+		if !ok {
+			return nil, fmt.Errorf("Unknown form factor %v", formFactor)
+		}
+		flags = append(flags, "--form-factor="+strings.ToLower(formFactor.String()))
+		if screen == nil {
+			screen = settings.screen
+		}
+		if userAgent == "" {
+			userAgent = settings.userAgent
+		}
+	}
+	if screen != nil {
+		flags = append(flags,
+			"--screenEmulation.mobile="+strconv.FormatBool(screen.GetMobile()),
+			"--screenEmulation.height="+strconv.Itoa(int(screen.GetHeight())),
+			"--screenEmulation.width="+strconv.Itoa(int(screen.GetWidth())),
+			"--screenEmulation.deviceScaleFactor="+formatFloat(screen.GetDeviceScaleFactor()))
+		if screen.GetDisabled() {
+			flags = append(flags, "--screenEmulation.disabled")
+		}
+	}
+	if userAgent != "" {
+		flags = append(flags, "--emulatedUserAgent="+userAgent)
+	}
+
+	if method := in.GetThrottlingMethod(); method != ThrottlingMethod_THROTTLING_METHOD_UNSPECIFIED {
+		if _, ok := ThrottlingMethod_name[int32(method)]; !ok {
+			return nil, fmt.Errorf("Unknown throttling method %v", method)
+		}
+		flags = append(flags, "--throttling-method="+strings.ToLower(method.String()))
+	}
+	if t := in.GetThrottling(); t != nil {
+		values := map[string]float64{
+			"rttMs":                  t.GetRttMs(),
+			"throughputKbps":         t.GetThroughputKbps(),
+			"requestLatencyMs":       t.GetRequestLatencyMs(),
+			"downloadThroughputKbps": t.GetDownloadThroughputKbps(),
+			"uploadThroughputKbps":   t.GetUploadThroughputKbps(),
+			"cpuSlowdownMultiplier":  t.GetCpuSlowdownMultiplier(),
+		}
+		for _, name := range []string{"throughputKbps", "rttMs", "cpuSlowdownMultiplier",
+			"requestLatencyMs", "downloadThroughputKbps", "uploadThroughputKbps"} {
+			if values[name] < 0 {
+				return nil, fmt.Errorf("Throttling value %s must not be negative", name)
+			}
+			flags = append(flags, fmt.Sprintf("--throttling.%s=%s", name, formatFloat(values[name])))
+		}
+	}
+
+	if locale := in.GetLocale(); locale != "" {
+		if !LocaleRegexp.MatchString(locale) {
+			return nil, fmt.Errorf("Invalid locale %q", locale)
+		}
+		flags = append(flags, "--locale="+locale)
+	}
+
+	if headers := in.GetExtraHeaders(); len(headers) > 0 {
+		for name, value := range headers {
+			if !HeaderNameRegexp.MatchString(name) {
+				return nil, fmt.Errorf("Invalid extra header name %q", name)
+			}
+			if strings.ContainsAny(value, "\r\n") {
+				return nil, fmt.Errorf("Invalid value for extra header %q", name)
+			}
+		}
+		b, err := json.Marshal(headers)
+		if err != nil {
+			return nil, err
+		}
+		flags = append(flags, "--extra-headers="+string(b))
+	}
+
+	for _, pattern := range in.GetBlockedUrlPatterns() {
+		if pattern == "" || strings.ContainsAny(pattern, " \t\r\n") {
+			return nil, fmt.Errorf("Invalid blocked URL pattern %q", pattern)
+		}
+		flags = append(flags, "--blocked-url-patterns="+pattern)
+	}
+	return flags, nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// Contains returns whether value is in values.
+func Contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package lighthouse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLighthouseCommandFormFactor(t *testing.T) {
+	command, err := lighthouseCommand(&LighthouseRequest{
+		Url:        "https://www.google.com",
+		FormFactor: FormFactor_MOBILE,
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	joined := strings.Join(command, " ")
+	if !strings.Contains(joined, "--form-factor=mobile") {
+		t.Errorf("Expected --form-factor=mobile in command %v", command)
+	}
+	if !strings.Contains(joined, "--screenEmulation.width=360") {
+		t.Errorf("Expected mobile screen emulation in command %v", command)
+	}
+}
+
+func TestLighthouseCommandOptions(t *testing.T) {
+	_, err := lighthouseCommand(&LighthouseRequest{
+		Url:        "https://www.google.com",
+		FormFactor: FormFactor_DESKTOP,
+		Options:    []string{"--form-factor=mobile"},
+	}, "")
+	if err == nil {
+		t.Error("Expected options combined with typed fields to be rejected")
+	}
+	command, err := lighthouseCommand(&LighthouseRequest{
+		Url:     "https://www.google.com",
+		Options: []string{"--emulated-form-factor=mobile"},
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if command[len(command)-1] != "--emulated-form-factor=mobile" {
+		t.Errorf("Expected the options at the end of command %v", command)
+	}
+}
+
+func TestLighthouseCommandTypedFields(t *testing.T) {
+	command, err := lighthouseCommand(&LighthouseRequest{
+		Url:              "https://www.google.com",
+		FormFactor:       FormFactor_DESKTOP,
+		ThrottlingMethod: ThrottlingMethod_DEVTOOLS,
+		Throttling: &Throttling{
+			RttMs:                 150,
+			ThroughputKbps:        1638.4,
+			CpuSlowdownMultiplier: 4,
+		},
+		Categories:         []string{"performance", "accessibility"},
+		Locale:             "de",
+		UserAgent:          "websu-test",
+		ExtraHeaders:       map[string]string{"Cookie": "session=abc"},
+		BlockedUrlPatterns: []string{"*.googletagmanager.com"},
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"--form-factor=desktop",
+		"--screenEmulation.width=1350",
+		"--emulatedUserAgent=websu-test",
+		"--throttling-method=devtools",
+		"--throttling.rttMs=150",
+		"--throttling.throughputKbps=1638.4",
+		"--throttling.cpuSlowdownMultiplier=4",
+		"--throttling.requestLatencyMs=0",
+		"--only-categories=performance,accessibility",
+		"--locale=de",
+		`--extra-headers={"Cookie":"session=abc"}`,
+		"--blocked-url-patterns=*.googletagmanager.com",
+	}
+	for _, flag := range expected {
+		if !Contains(command, flag) {
+			t.Errorf("Expected flag %s in command %v", flag, command)
+		}
+	}
+}
+
+func TestLighthouseCommandArtifacts(t *testing.T) {
+	command, err := lighthouseCommand(&LighthouseRequest{
+		Url:       "https://www.google.com",
+		Artifacts: []ArtifactType{ArtifactType_HTML_REPORT, ArtifactType_TRACE, ArtifactType_FINAL_SCREENSHOT},
+	}, "/tmp/out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"--output=json",
+		"--output=html",
+		"--output-path=/tmp/out/lighthouse",
+		"--save-assets",
+		"--skip-audits=apple-touch-icon,screenshot-thumbnails",
+	}
+	for _, flag := range expected {
+		if !Contains(command, flag) {
+			t.Errorf("Expected flag %s in command %v", flag, command)
+		}
+	}
+	if Contains(command, "--output-path=stdout") {
+		t.Errorf("Expected the output to be written to the output dir in command %v", command)
+	}
+}
+
+func TestLighthouseCommandInvalid(t *testing.T) {
+	tests := map[string]*LighthouseRequest{
+		"category":   {Categories: []string{"speed"}},
+		"locale":     {Locale: "en US --config-path=/etc/passwd"},
+		"header":     {ExtraHeaders: map[string]string{"Bad Header": "value"}},
+		"throttling": {Throttling: &Throttling{RttMs: -1}},
+		"pattern":    {BlockedUrlPatterns: []string{""}},
+		"artifact":   {Artifacts: []ArtifactType{ArtifactType(42)}},
+	}
+	for name, in := range tests {
+		in.Url = "https://www.google.com"
+		if _, err := lighthouseCommand(in, ""); err == nil {
+			t.Errorf("Expected an error for an invalid %s", name)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package lighthouse
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	neturl "net/url"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// MaxFlowSteps is the maximum number of steps of a user flow
+	MaxFlowSteps             = 50
+	flowScriptFile           = "flow.js"
+	flowInputFile            = "flow.json"
+	defaultFlowStepTimeoutMs = 30000
+)
+
+// flowScript runs a lighthouse user flow with puppeteer. The lighthouse and
+// puppeteer-core node modules must be resolvable, e.g. through NODE_PATH, and
+// CHROME_PATH must point to the Chrome executable. The flow runs in a new
+// incognito context of an already running Chrome when a port is set.
+const flowScript = `'use strict';
+const fs = require('fs');
+const puppeteer = require('puppeteer-core');
+const path = require('path');
+const url = require('url');
+
+// lighthouseDir returns the directory of the lighthouse package.
+function lighthouseDir() {
+  let dir = path.dirname(require.resolve('lighthouse'));
+  while (!fs.existsSync(path.join(dir, 'package.json'))) {
+    dir = path.dirname(dir);
+  }
+  return dir;
+}
+
+// loadStartFlow supports the fraggle-rock API of lighthouse 9 and the ES
+// module of lighthouse 10 and newer, which exports startFlow.
+async function loadStartFlow(dir) {
+  const legacy = path.join(dir, 'lighthouse-core', 'fraggle-rock', 'api.js');
+  if (fs.existsSync(legacy)) {
+    return require(legacy).startFlow;
+  }
+  return (await import(url.pathToFileURL(require.resolve('lighthouse')).href)).startFlow;
+}
+
+async function main() {
+  const input = JSON.parse(fs.readFileSync(process.argv[2], 'utf8'));
+  const dir = lighthouseDir();
+  const startFlow = await loadStartFlow(dir);
+  const log = require(require.resolve('lighthouse-logger', {paths: [dir]}));
+  log.setLevel(input.verbose ? 'verbose' : 'info');
+  const browser = input.port ?
+    await puppeteer.connect({browserURL: 'http://127.0.0.1:' + input.port}) :
+    await puppeteer.launch({
+      executablePath: process.env.CHROME_PATH,
+      args: input.chromeFlags,
+    });
+  const context = input.port ? await browser.createIncognitoBrowserContext() : browser;
+  try {
+    const page = await context.newPage();
+    const flow = await startFlow(page, {name: input.name, config: input.config});
+    for (const step of input.steps) {
+      console.error('LH:status Running step ' + (step.name || step.type));
+      const stepFlags = step.name ? {stepName: step.name} : undefined;
+      switch (step.type) {
+        case 'NAVIGATE': await flow.navigate(step.url, stepFlags); break;
+        case 'CLICK':
+          await page.waitForSelector(step.selector, {timeout: step.timeoutMs});
+          await page.click(step.selector);
+          break;
+        case 'TYPE':
+          await page.waitForSelector(step.selector, {timeout: step.timeoutMs});
+          await page.type(step.selector, step.text);
+          break;
+        case 'WAIT_FOR_SELECTOR': await page.waitForSelector(step.selector, {timeout: step.timeoutMs}); break;
+        case 'START_TIMESPAN': await flow.startTimespan(stepFlags); break;
+        case 'END_TIMESPAN': await flow.endTimespan(); break;
+        case 'SNAPSHOT': await flow.snapshot(stepFlags); break;
+        default: throw new Error('Unknown step type ' + step.type);
+      }
+    }
+    console.error('LH:status Auditing flow');
+    fs.writeFileSync(input.outputPath + '.report.json', JSON.stringify(await flow.createFlowResult()));
+    if (input.html) {
+      fs.writeFileSync(input.outputPath + '.report.html', await flow.generateReport());
+    }
+  } finally {
+    if (input.port) {
+      await context.close();
+      browser.disconnect();
+    } else {
+      await browser.close();
+    }
+  }
+}
+
+main().catch(err => {
+  console.error(err);
+  process.exit(1);
+});
+`
+
+type flowStepInput struct {
+	Type      string `json:"type"`
+	URL       string `json:"url,omitempty"`
+	Selector  string `json:"selector,omitempty"`
+	Text      string `json:"text,omitempty"`
+	Name      string `json:"name,omitempty"`
+	TimeoutMs int32  `json:"timeoutMs,omitempty"`
+}
+
+// flowInput is the input of flowScript.
+type flowInput struct {
+	Name        string                 `json:"name"`
+	Steps       []flowStepInput        `json:"steps"`
+	Config      map[string]interface{} `json:"config"`
+	ChromeFlags []string               `json:"chromeFlags"`
+	OutputPath  string                 `json:"outputPath"`
+	HTML        bool                   `json:"html"`
+	// Port is the DevTools port of the pooled Chrome the flow connects to
+	Port    int  `json:"port,omitempty"`
+	Verbose bool `json:"verbose"`
+}
+
+func isFlow(in *LighthouseRequest) bool {
+	return len(in.GetFlowSteps()) > 0
+}
+
+// flowSteps validates the steps of the flow. The flow starts by navigating
+// to the URL of the request when the first step isn't a navigation.
+func flowSteps(in *LighthouseRequest) ([]flowStepInput, error) {
+	steps := in.GetFlowSteps()
+	if len(steps) > MaxFlowSteps {
+		return nil, fmt.Errorf("A flow can have at most %d steps", MaxFlowSteps)
+	}
+	if steps[0].GetType() != FlowStep_NAVIGATE {
+		if in.GetUrl() == "" {
+			return nil, fmt.Errorf("The url is required when the flow doesn't start with a NAVIGATE step")
+		}
+		steps = append([]*FlowStep{{Type: FlowStep_NAVIGATE, Url: in.GetUrl()}}, steps...)
+	}
+	inputs := []flowStepInput{}
+	inTimespan := false
+	for i, step := range steps {
+		input := flowStepInput{Type: step.GetType().String(), Name: step.GetName(), TimeoutMs: step.GetTimeoutMs()}
+		switch step.GetType() {
+		case FlowStep_NAVIGATE:
+			u, err := neturl.Parse(step.GetUrl())
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				return nil, fmt.Errorf("Step %d needs an http or https url", i+1)
+			}
+			input.URL = step.GetUrl()
+		case FlowStep_CLICK, FlowStep_TYPE, FlowStep_WAIT_FOR_SELECTOR:
+			if step.GetSelector() == "" {
+				return nil, fmt.Errorf("Step %d of type %v needs a selector", i+1, step.GetType())
+			}
+			input.Selector = step.GetSelector()
+			input.Text = step.GetText()
+			if input.TimeoutMs <= 0 {
+				input.TimeoutMs = defaultFlowStepTimeoutMs
+			}
+		case FlowStep_START_TIMESPAN:
+			if inTimespan {
+				return nil, fmt.Errorf("Step %d starts a timespan before the previous one ended", i+1)
+			}
+			inTimespan = true
+		case FlowStep_END_TIMESPAN:
+			if !inTimespan {
+				return nil, fmt.Errorf("Step %d ends a timespan that wasn't started", i+1)
+			}
+			inTimespan = false
+		case FlowStep_SNAPSHOT:
+		default:
+			return nil, fmt.Errorf("Step %d has unknown type %v", i+1, step.GetType())
+		}
+		if inTimespan && (step.GetType() == FlowStep_NAVIGATE || step.GetType() == FlowStep_SNAPSHOT) {
+			return nil, fmt.Errorf("Step %d of type %v can't run during a timespan", i+1, step.GetType())
+		}
+		inputs = append(inputs, input)
+	}
+	if inTimespan {
+		return nil, fmt.Errorf("The flow ends before the timespan ended")
+	}
+	return inputs, nil
+}
+
+// flowSettings maps the typed fields of the request to the lighthouse config
+// settings used by the flow.
+func flowSettings(in *LighthouseRequest) (map[string]interface{}, error) {
+	// typedFlags validates the fields shared with the lighthouse CLI
+	if _, err := typedFlags(in); err != nil {
+		return nil, err
+	}
+	settings := map[string]interface{}{}
+	categories := in.GetCategories()
+	if len(categories) == 0 {
+		categories = defaultCategories
+	}
+	settings["onlyCategories"] = categories
+	settings["skipAudits"] = []string{"apple-touch-icon"}
+	screen := in.GetScreenEmulation()
+	userAgent := in.GetUserAgent()
+	if settingsFor, ok := formFactorSettings[in.GetFormFactor()]; ok {
+		settings["formFactor"] = strings.ToLower(in.GetFormFactor().String())
+		if screen == nil {
+			screen = settingsFor.screen
+		}
+		if userAgent == "" {
+			userAgent = settingsFor.userAgent
+		}
+	}
+	if screen != nil {
+		settings["screenEmulation"] = map[string]interface{}{
+			"mobile":            screen.GetMobile(),
+			"width":             screen.GetWidth(),
+			"height":            screen.GetHeight(),
+			"deviceScaleFactor": screen.GetDeviceScaleFactor(),
+			"disabled":          screen.GetDisabled(),
+		}
+	}
+	if userAgent != "" {
+		settings["emulatedUserAgent"] = userAgent
+	}
+	if method := in.GetThrottlingMethod(); method != ThrottlingMethod_THROTTLING_METHOD_UNSPECIFIED {
+		settings["throttlingMethod"] = strings.ToLower(method.String())
+	}
+	if t := in.GetThrottling(); t != nil {
+		settings["throttling"] = map[string]float64{
+			"rttMs":                  t.GetRttMs(),
+			"throughputKbps":         t.GetThroughputKbps(),
+			"requestLatencyMs":       t.GetRequestLatencyMs(),
+			"downloadThroughputKbps": t.GetDownloadThroughputKbps(),
+			"uploadThroughputKbps":   t.GetUploadThroughputKbps(),
+			"cpuSlowdownMultiplier":  t.GetCpuSlowdownMultiplier(),
+		}
+	}
+	if locale := in.GetLocale(); locale != "" {
+		settings["locale"] = locale
+	}
+	if headers := in.GetExtraHeaders(); len(headers) > 0 {
+		settings["extraHeaders"] = headers
+	}
+	if patterns := in.GetBlockedUrlPatterns(); len(patterns) > 0 {
+		settings["blockedUrlPatterns"] = patterns
+	}
+	return settings, nil
+}
+
+// newFlowInput validates the flow of the request and returns the input of
+// flowScript.
+func newFlowInput(in *LighthouseRequest, outputDir string) (*flowInput, error) {
+	if len(in.GetOptions()) > 0 {
+		return nil, fmt.Errorf("Options aren't supported by user flows, use the typed fields instead")
+	}
+	for _, a := range in.GetArtifacts() {
+		if a != ArtifactType_HTML_REPORT {
+			return nil, fmt.Errorf("User flows only support the HTML_REPORT artifact")
+		}
+	}
+	steps, err := flowSteps(in)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := flowSettings(in)
+	if err != nil {
+		return nil, err
+	}
+	config := map[string]interface{}{"extends": "lighthouse:default"}
+	if hasConfig(in) {
+		if config, err = customConfig(in); err != nil {
+			return nil, err
+		}
+		if custom, ok := config["settings"].(map[string]interface{}); ok {
+			for k, v := range settings {
+				custom[k] = v
+			}
+			settings = custom
+		}
+	}
+	config["settings"] = settings
+	return &flowInput{
+		Name:        "User flow of " + steps[0].URL,
+		Steps:       steps,
+		Config:      config,
+		ChromeFlags: append(append([]string{}, defaultChromeflags...), in.GetChromeflags()...),
+		OutputPath:  filepath.Join(outputDir, reportBasename),
+		HTML:        hasArtifact(in, ArtifactType_HTML_REPORT),
+		Verbose:     in.GetVerbose(),
+	}, nil
+}
+
+// flowCommand writes the flow script and its input to outputDir and returns
+// the command that runs the flow.
+func flowCommand(input *flowInput, outputDir string) ([]string, error) {
+	b, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+	scriptPath := filepath.Join(outputDir, flowScriptFile)
+	inputPath := filepath.Join(outputDir, flowInputFile)
+	if err := ioutil.WriteFile(scriptPath, []byte(flowScript), 0644); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(inputPath, b, 0644); err != nil {
+		return nil, err
+	}
+	return []string{"node", scriptPath, inputPath}, nil
+}
+
+// fakeFlowResult builds a flow result from a single lighthouse result with
+// one step for every auditing step of the flow.
+func fakeFlowResult(input *flowInput, lhr []byte) ([]byte, error) {
+	type step struct {
+		LHR  json.RawMessage `json:"lhr"`
+		Name string          `json:"name"`
+	}
+	result := struct {
+		Name  string `json:"name"`
+		Steps []step `json:"steps"`
+	}{Name: input.Name, Steps: []step{}}
+	for _, s := range input.Steps {
+		var gatherMode string
+		switch s.Type {
+		case FlowStep_NAVIGATE.String():
+			gatherMode = "navigation"
+		case FlowStep_END_TIMESPAN.String():
+			gatherMode = "timespan"
+		case FlowStep_SNAPSHOT.String():
+			gatherMode = "snapshot"
+		default:
+			continue
+		}
+		var stepLHR map[string]interface{}
+		if err := json.Unmarshal(lhr, &stepLHR); err != nil {
+			return nil, err
+		}
+		stepLHR["gatherMode"] = gatherMode
+		b, err := json.Marshal(stepLHR)
+		if err != nil {
+			return nil, err
+		}
+		name := s.Name
+		if name == "" {
+			name = gatherMode
+		}
+		result.Steps = append(result.Steps, step{LHR: b, Name: name})
+	}
+	return json.Marshal(result)
+}
@@ -0,0 +1,111 @@
+package lighthouse
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFlowSteps(t *testing.T) {
+	steps, err := flowSteps(&LighthouseRequest{
+		Url: "https://www.google.com",
+		FlowSteps: []*FlowStep{
+			{Type: FlowStep_START_TIMESPAN, Name: "Search"},
+			{Type: FlowStep_TYPE, Selector: "input[name=q]", Text: "websu"},
+			{Type: FlowStep_CLICK, Selector: "input[type=submit]"},
+			{Type: FlowStep_END_TIMESPAN},
+			{Type: FlowStep_SNAPSHOT},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 6 || steps[0].Type != "NAVIGATE" || steps[0].URL != "https://www.google.com" {
+		t.Fatalf("Expected the flow to start by navigating to the url, but got %+v", steps)
+	}
+	if steps[2].TimeoutMs != defaultFlowStepTimeoutMs {
+		t.Errorf("Expected the default timeout for step TYPE, but got %d", steps[2].TimeoutMs)
+	}
+}
+
+func TestFlowStepsInvalid(t *testing.T) {
+	tests := map[string][]*FlowStep{
+		"navigate without url":  {{Type: FlowStep_NAVIGATE}},
+		"click without select":  {{Type: FlowStep_CLICK}},
+		"unended timespan":      {{Type: FlowStep_START_TIMESPAN}},
+		"unstarted timespan":    {{Type: FlowStep_END_TIMESPAN}},
+		"snapshot in timespan":  {{Type: FlowStep_START_TIMESPAN}, {Type: FlowStep_SNAPSHOT}, {Type: FlowStep_END_TIMESPAN}},
+		"unspecified step type": {{Type: FlowStep_TYPE_UNSPECIFIED}},
+	}
+	for name, steps := range tests {
+		if _, err := flowSteps(&LighthouseRequest{Url: "https://www.google.com", FlowSteps: steps}); err == nil {
+			t.Errorf("Expected an error for a flow with %s", name)
+		}
+	}
+}
+
+func TestFlowSettings(t *testing.T) {
+	settings, err := flowSettings(&LighthouseRequest{
+		FormFactor:   FormFactor_MOBILE,
+		Categories:   []string{"performance"},
+		ExtraHeaders: map[string]string{"Cookie": "session=abc"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings["formFactor"] != "mobile" || settings["screenEmulation"] == nil {
+		t.Errorf("Expected mobile emulation settings, but got %v", settings)
+	}
+	if categories := settings["onlyCategories"].([]string); len(categories) != 1 || categories[0] != "performance" {
+		t.Errorf("Expected only the performance category, but got %v", categories)
+	}
+	if _, err := flowSettings(&LighthouseRequest{Locale: "not a locale"}); err == nil {
+		t.Error("Expected an error for an invalid locale")
+	}
+}
+
+func TestRunFlow(t *testing.T) {
+	s := &Server{Runner: &FakeRunner{Dir: "testdata"}}
+	result, err := s.Run(context.Background(), &LighthouseRequest{
+		Url: "https://www.google.com",
+		FlowSteps: []*FlowStep{
+			{Type: FlowStep_START_TIMESPAN},
+			{Type: FlowStep_CLICK, Selector: "a"},
+			{Type: FlowStep_END_TIMESPAN},
+			{Type: FlowStep_SNAPSHOT, Name: "After click"},
+		},
+		Artifacts: []ArtifactType{ArtifactType_HTML_REPORT},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var flow struct {
+		Steps []struct {
+			Name string `json:"name"`
+			LHR  struct {
+				GatherMode string `json:"gatherMode"`
+			} `json:"lhr"`
+		} `json:"steps"`
+	}
+	if err := json.Unmarshal(result.GetStdout(), &flow); err != nil {
+		t.Fatal(err)
+	}
+	if len(flow.Steps) != 3 || flow.Steps[1].LHR.GatherMode != "timespan" || flow.Steps[2].Name != "After click" {
+		t.Errorf("Unexpected flow result steps %+v", flow.Steps)
+	}
+	if len(result.GetArtifacts()) != 2 || result.GetArtifacts()[0].GetType() != ArtifactType_HTML_REPORT {
+		t.Errorf("Expected the HTML flow report and the logs, but got %v", result.GetArtifacts())
+	}
+
+	_, err = s.Run(context.Background(), &LighthouseRequest{
+		Url:       "https://www.google.com",
+		FlowSteps: []*FlowStep{{Type: FlowStep_SNAPSHOT}},
+		Options:   []string{"--locale=de"},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected code InvalidArgument for options in a flow, but got %v", err)
+	}
+}
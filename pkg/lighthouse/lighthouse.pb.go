@@ -25,33 +25,1359 @@ const (
 // of the legacy proto package is being used.
 const _ = proto.ProtoPackageIsVersion4
 
+type Compression int32
+
+const (
+	Compression_NONE Compression = 0
+	Compression_GZIP Compression = 1
+)
+
+// Enum value maps for Compression.
+var (
+	Compression_name = map[int32]string{
+		0: "NONE",
+		1: "GZIP",
+	}
+	Compression_value = map[string]int32{
+		"NONE": 0,
+		"GZIP": 1,
+	}
+)
+
+func (x Compression) Enum() *Compression {
+	p := new(Compression)
+	*p = x
+	return p
+}
+
+func (x Compression) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Compression) Descriptor() protoreflect.EnumDescriptor {
+	return file_lighthouse_proto_enumTypes[0].Descriptor()
+}
+
+func (Compression) Type() protoreflect.EnumType {
+	return &file_lighthouse_proto_enumTypes[0]
+}
+
+func (x Compression) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Compression.Descriptor instead.
+func (Compression) EnumDescriptor() ([]byte, []int) {
+	return file_lighthouse_proto_rawDescGZIP(), []int{0}
+}
+
+type FormFactor int32
+
+const (
+	FormFactor_FORM_FACTOR_UNSPECIFIED FormFactor = 0
+	FormFactor_DESKTOP                 FormFactor = 1
+	FormFactor_MOBILE                  FormFactor = 2
+)
+
+// Enum value maps for FormFactor.
+var (
+	FormFactor_name = map[int32]string{
+		0: "FORM_FACTOR_UNSPECIFIED",
+		1: "DESKTOP",
+		2: "MOBILE",
+	}
+	FormFactor_value = map[string]int32{
+		"FORM_FACTOR_UNSPECIFIED": 0,
+		"DESKTOP":                 1,
+		"MOBILE":                  2,
+	}
+)
+
+func (x FormFactor) Enum() *FormFactor {
+	p := new(FormFactor)
+	*p = x
+	return p
+}
+
+func (x FormFactor) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FormFactor) Descriptor() protoreflect.EnumDescriptor {
+	return file_lighthouse_proto_enumTypes[1].Descriptor()
+}
+
+func (FormFactor) Type() protoreflect.EnumType {
+	return &file_lighthouse_proto_enumTypes[1]
+}
+
+func (x FormFactor) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FormFactor.Descriptor instead.
+func (FormFactor) EnumDescriptor() ([]byte, []int) {
+	return file_lighthouse_proto_rawDescGZIP(), []int{1}
+}
+
+type ThrottlingMethod int32
+
+const (
+	ThrottlingMethod_THROTTLING_METHOD_UNSPECIFIED ThrottlingMethod = 0
+	ThrottlingMethod_SIMULATE                      ThrottlingMethod = 1
+	ThrottlingMethod_DEVTOOLS                      ThrottlingMethod = 2
+	ThrottlingMethod_PROVIDED                      ThrottlingMethod = 3
+)
+
+// Enum value maps for ThrottlingMethod.
+var (
+	ThrottlingMethod_name = map[int32]string{
+		0: "THROTTLING_METHOD_UNSPECIFIED",
+		1: "SIMULATE",
+		2: "DEVTOOLS",
+		3: "PROVIDED",
+	}
+	ThrottlingMethod_value = map[string]int32{
+		"THROTTLING_METHOD_UNSPECIFIED": 0,
+		"SIMULATE":                      1,
+		"DEVTOOLS":                      2,
+		"PROVIDED":                      3,
+	}
+)
+
+func (x ThrottlingMethod) Enum() *ThrottlingMethod {
+	p := new(ThrottlingMethod)
+	*p = x
+	return p
+}
+
+func (x ThrottlingMethod) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ThrottlingMethod) Descriptor() protoreflect.EnumDescriptor {
+	return file_lighthouse_proto_enumTypes[2].Descriptor()
+}
+
+func (ThrottlingMethod) Type() protoreflect.EnumType {
+	return &file_lighthouse_proto_enumTypes[2]
+}
+
+func (x ThrottlingMethod) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ThrottlingMethod.Descriptor instead.
+func (ThrottlingMethod) EnumDescriptor() ([]byte, []int) {
+	return file_lighthouse_proto_rawDescGZIP(), []int{2}
+}
+
+type ArtifactType int32
+
+const (
+	ArtifactType_ARTIFACT_TYPE_UNSPECIFIED ArtifactType = 0
+	// The lighthouse HTML report
+	ArtifactType_HTML_REPORT      ArtifactType = 1
+	ArtifactType_FINAL_SCREENSHOT ArtifactType = 2
+	// The filmstrip of screenshots taken while the page was loading
+	ArtifactType_SCREENSHOT_THUMBNAILS ArtifactType = 3
+	// The devtools trace of the page load
+	ArtifactType_TRACE ArtifactType = 4
+	// The log output of lighthouse, which is always returned
+	ArtifactType_LOGS ArtifactType = 5
+)
+
+// Enum value maps for ArtifactType.
+var (
+	ArtifactType_name = map[int32]string{
+		0: "ARTIFACT_TYPE_UNSPECIFIED",
+		1: "HTML_REPORT",
+		2: "FINAL_SCREENSHOT",
+		3: "SCREENSHOT_THUMBNAILS",
+		4: "TRACE",
+		5: "LOGS",
+	}
+	ArtifactType_value = map[string]int32{
+		"ARTIFACT_TYPE_UNSPECIFIED": 0,
+		"HTML_REPORT":               1,
+		"FINAL_SCREENSHOT":          2,
+		"SCREENSHOT_THUMBNAILS":     3,
+		"TRACE":                     4,
+		"LOGS":                      5,
+	}
+)
+
+func (x ArtifactType) Enum() *ArtifactType {
+	p := new(ArtifactType)
+	*p = x
+	return p
+}
+
+func (x ArtifactType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ArtifactType) Descriptor() protoreflect.EnumDescriptor {
+	return file_lighthouse_proto_enumTypes[3].Descriptor()
+}
+
+func (ArtifactType) Type() protoreflect.EnumType {
+	return &file_lighthouse_proto_enumTypes[3]
+}
+
+func (x ArtifactType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ArtifactType.Descriptor instead.
+func (ArtifactType) EnumDescriptor() ([]byte, []int) {
+	return file_lighthouse_proto_rawDescGZIP(), []int{3}
+}
+
+type FlowStep_Type int32
+
+const (
+	FlowStep_TYPE_UNSPECIFIED FlowStep_Type = 0
+	// Navigates to url and audits the page load
+	FlowStep_NAVIGATE FlowStep_Type = 1
+	// Clicks the element matching selector
+	FlowStep_CLICK FlowStep_Type = 2
+	// Types text into the element matching selector
+	FlowStep_TYPE FlowStep_Type = 3
+	// Waits until an element matches selector
+	FlowStep_WAIT_FOR_SELECTOR FlowStep_Type = 4
+	// Starts auditing the interactions until END_TIMESPAN
+	FlowStep_START_TIMESPAN FlowStep_Type = 5
+	FlowStep_END_TIMESPAN   FlowStep_Type = 6
+	// Audits the current state of the page
+	FlowStep_SNAPSHOT FlowStep_Type = 7
+)
+
+// Enum value maps for FlowStep_Type.
+var (
+	FlowStep_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "NAVIGATE",
+		2: "CLICK",
+		3: "TYPE",
+		4: "WAIT_FOR_SELECTOR",
+		5: "START_TIMESPAN",
+		6: "END_TIMESPAN",
+		7: "SNAPSHOT",
+	}
+	FlowStep_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED":  0,
+		"NAVIGATE":          1,
+		"CLICK":             2,
+		"TYPE":              3,
+		"WAIT_FOR_SELECTOR": 4,
+		"START_TIMESPAN":    5,
+		"END_TIMESPAN":      6,
+		"SNAPSHOT":          7,
+	}
+)
+
+func (x FlowStep_Type) Enum() *FlowStep_Type {
+	p := new(FlowStep_Type)
+	*p = x
+	return p
+}
+
+func (x FlowStep_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FlowStep_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_lighthouse_proto_enumTypes[4].Descriptor()
+}
+
+func (FlowStep_Type) Type() protoreflect.EnumType {
+	return &file_lighthouse_proto_enumTypes[4]
+}
+
+func (x FlowStep_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FlowStep_Type.Descriptor instead.
+func (FlowStep_Type) EnumDescriptor() ([]byte, []int) {
+	return file_lighthouse_proto_rawDescGZIP(), []int{3, 0}
+}
+
+type Progress_Stage int32
+
+const (
+	Progress_UNKNOWN   Progress_Stage = 0
+	Progress_QUEUED    Progress_Stage = 1
+	Progress_STARTED   Progress_Stage = 2
+	Progress_GATHERING Progress_Stage = 3
+	Progress_AUDITING  Progress_Stage = 4
+	Progress_COMPLETED Progress_Stage = 5
+)
+
+// Enum value maps for Progress_Stage.
+var (
+	Progress_Stage_name = map[int32]string{
+		0: "UNKNOWN",
+		1: "QUEUED",
+		2: "STARTED",
+		3: "GATHERING",
+		4: "AUDITING",
+		5: "COMPLETED",
+	}
+	Progress_Stage_value = map[string]int32{
+		"UNKNOWN":   0,
+		"QUEUED":    1,
+		"STARTED":   2,
+		"GATHERING": 3,
+		"AUDITING":  4,
+		"COMPLETED": 5,
+	}
+)
+
+func (x Progress_Stage) Enum() *Progress_Stage {
+	p := new(Progress_Stage)
+	*p = x
+	return p
+}
+
+func (x Progress_Stage) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Progress_Stage) Descriptor() protoreflect.EnumDescriptor {
+	return file_lighthouse_proto_enumTypes[5].Descriptor()
+}
+
+func (Progress_Stage) Type() protoreflect.EnumType {
+	return &file_lighthouse_proto_enumTypes[5]
+}
+
+func (x Progress_Stage) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Progress_Stage.Descriptor instead.
+func (Progress_Stage) EnumDescriptor() ([]byte, []int) {
+	return file_lighthouse_proto_rawDescGZIP(), []int{7, 0}
+}
+
+// Throttling settings of lighthouse. All values are passed to lighthouse
+// when the message is set, so zero means no throttling.
+type Throttling struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RttMs                  float64 `protobuf:"fixed64,1,opt,name=rtt_ms,json=rttMs,proto3" json:"rtt_ms,omitempty"`
+	ThroughputKbps         float64 `protobuf:"fixed64,2,opt,name=throughput_kbps,json=throughputKbps,proto3" json:"throughput_kbps,omitempty"`
+	RequestLatencyMs       float64 `protobuf:"fixed64,3,opt,name=request_latency_ms,json=requestLatencyMs,proto3" json:"request_latency_ms,omitempty"`
+	DownloadThroughputKbps float64 `protobuf:"fixed64,4,opt,name=download_throughput_kbps,json=downloadThroughputKbps,proto3" json:"download_throughput_kbps,omitempty"`
+	UploadThroughputKbps   float64 `protobuf:"fixed64,5,opt,name=upload_throughput_kbps,json=uploadThroughputKbps,proto3" json:"upload_throughput_kbps,omitempty"`
+	CpuSlowdownMultiplier  float64 `protobuf:"fixed64,6,opt,name=cpu_slowdown_multiplier,json=cpuSlowdownMultiplier,proto3" json:"cpu_slowdown_multiplier,omitempty"`
+}
+
+func (x *Throttling) Reset() {
+	*x = Throttling{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lighthouse_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Throttling) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Throttling) ProtoMessage() {}
+
+func (x *Throttling) ProtoReflect() protoreflect.Message {
+	mi := &file_lighthouse_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Throttling.ProtoReflect.Descriptor instead.
+func (*Throttling) Descriptor() ([]byte, []int) {
+	return file_lighthouse_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Throttling) GetRttMs() float64 {
+	if x != nil {
+		return x.RttMs
+	}
+	return 0
+}
+
+func (x *Throttling) GetThroughputKbps() float64 {
+	if x != nil {
+		return x.ThroughputKbps
+	}
+	return 0
+}
+
+func (x *Throttling) GetRequestLatencyMs() float64 {
+	if x != nil {
+		return x.RequestLatencyMs
+	}
+	return 0
+}
+
+func (x *Throttling) GetDownloadThroughputKbps() float64 {
+	if x != nil {
+		return x.DownloadThroughputKbps
+	}
+	return 0
+}
+
+func (x *Throttling) GetUploadThroughputKbps() float64 {
+	if x != nil {
+		return x.UploadThroughputKbps
+	}
+	return 0
+}
+
+func (x *Throttling) GetCpuSlowdownMultiplier() float64 {
+	if x != nil {
+		return x.CpuSlowdownMultiplier
+	}
+	return 0
+}
+
+type Artifact struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type        ArtifactType `protobuf:"varint,1,opt,name=type,proto3,enum=lighthouse.ArtifactType" json:"type,omitempty"`
+	Name        string       `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	ContentType string       `protobuf:"bytes,3,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Data        []byte       `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	// Milliseconds since the navigation started, set for screenshot thumbnails
+	Timing float64 `protobuf:"fixed64,5,opt,name=timing,proto3" json:"timing,omitempty"`
+}
+
+func (x *Artifact) Reset() {
+	*x = Artifact{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lighthouse_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Artifact) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Artifact) ProtoMessage() {}
+
+func (x *Artifact) ProtoReflect() protoreflect.Message {
+	mi := &file_lighthouse_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Artifact.ProtoReflect.Descriptor instead.
+func (*Artifact) Descriptor() ([]byte, []int) {
+	return file_lighthouse_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Artifact) GetType() ArtifactType {
+	if x != nil {
+		return x.Type
+	}
+	return ArtifactType_ARTIFACT_TYPE_UNSPECIFIED
+}
+
+func (x *Artifact) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Artifact) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *Artifact) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Artifact) GetTiming() float64 {
+	if x != nil {
+		return x.Timing
+	}
+	return 0
+}
+
+type ScreenEmulation struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Mobile            bool    `protobuf:"varint,1,opt,name=mobile,proto3" json:"mobile,omitempty"`
+	Width             int32   `protobuf:"varint,2,opt,name=width,proto3" json:"width,omitempty"`
+	Height            int32   `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`
+	DeviceScaleFactor float64 `protobuf:"fixed64,4,opt,name=device_scale_factor,json=deviceScaleFactor,proto3" json:"device_scale_factor,omitempty"`
+	Disabled          bool    `protobuf:"varint,5,opt,name=disabled,proto3" json:"disabled,omitempty"`
+}
+
+func (x *ScreenEmulation) Reset() {
+	*x = ScreenEmulation{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lighthouse_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ScreenEmulation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScreenEmulation) ProtoMessage() {}
+
+func (x *ScreenEmulation) ProtoReflect() protoreflect.Message {
+	mi := &file_lighthouse_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScreenEmulation.ProtoReflect.Descriptor instead.
+func (*ScreenEmulation) Descriptor() ([]byte, []int) {
+	return file_lighthouse_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ScreenEmulation) GetMobile() bool {
+	if x != nil {
+		return x.Mobile
+	}
+	return false
+}
+
+func (x *ScreenEmulation) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *ScreenEmulation) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *ScreenEmulation) GetDeviceScaleFactor() float64 {
+	if x != nil {
+		return x.DeviceScaleFactor
+	}
+	return 0
+}
+
+func (x *ScreenEmulation) GetDisabled() bool {
+	if x != nil {
+		return x.Disabled
+	}
+	return false
+}
+
+// FlowStep is a single step of a lighthouse user flow
+type FlowStep struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type     FlowStep_Type `protobuf:"varint,1,opt,name=type,proto3,enum=lighthouse.FlowStep_Type" json:"type,omitempty"`
+	Url      string        `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	Selector string        `protobuf:"bytes,3,opt,name=selector,proto3" json:"selector,omitempty"`
+	Text     string        `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	// Name of the step in the flow report
+	Name string `protobuf:"bytes,5,opt,name=name,proto3" json:"name,omitempty"`
+	// Timeout of WAIT_FOR_SELECTOR, defaults to 30 seconds
+	TimeoutMs int32 `protobuf:"varint,6,opt,name=timeout_ms,json=timeoutMs,proto3" json:"timeout_ms,omitempty"`
+}
+
+func (x *FlowStep) Reset() {
+	*x = FlowStep{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lighthouse_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FlowStep) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlowStep) ProtoMessage() {}
+
+func (x *FlowStep) ProtoReflect() protoreflect.Message {
+	mi := &file_lighthouse_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlowStep.ProtoReflect.Descriptor instead.
+func (*FlowStep) Descriptor() ([]byte, []int) {
+	return file_lighthouse_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *FlowStep) GetType() FlowStep_Type {
+	if x != nil {
+		return x.Type
+	}
+	return FlowStep_TYPE_UNSPECIFIED
+}
+
+func (x *FlowStep) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *FlowStep) GetSelector() string {
+	if x != nil {
+		return x.Selector
+	}
+	return ""
+}
+
+func (x *FlowStep) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *FlowStep) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *FlowStep) GetTimeoutMs() int32 {
+	if x != nil {
+		return x.TimeoutMs
+	}
+	return 0
+}
+
 type LighthouseRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Url         string   `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
-	Options     []string `protobuf:"bytes,2,rep,name=options,proto3" json:"options,omitempty"`
-	Chromeflags []string `protobuf:"bytes,3,rep,name=chromeflags,proto3" json:"chromeflags,omitempty"`
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// Deprecated: extra lighthouse CLI flags, use the typed fields instead
+	Options     []string `protobuf:"bytes,2,rep,name=options,proto3" json:"options,omitempty"`
+	Chromeflags []string `protobuf:"bytes,3,rep,name=chromeflags,proto3" json:"chromeflags,omitempty"`
+	// Compression of the result chunks sent by RunStream
+	ResultCompression Compression      `protobuf:"varint,4,opt,name=result_compression,json=resultCompression,proto3,enum=lighthouse.Compression" json:"result_compression,omitempty"`
+	FormFactor        FormFactor       `protobuf:"varint,5,opt,name=form_factor,json=formFactor,proto3,enum=lighthouse.FormFactor" json:"form_factor,omitempty"`
+	ThrottlingMethod  ThrottlingMethod `protobuf:"varint,6,opt,name=throttling_method,json=throttlingMethod,proto3,enum=lighthouse.ThrottlingMethod" json:"throttling_method,omitempty"`
+	Throttling        *Throttling      `protobuf:"bytes,7,opt,name=throttling,proto3" json:"throttling,omitempty"`
+	// Lighthouse categories to run, defaults to best-practices, performance and seo
+	Categories []string `protobuf:"bytes,8,rep,name=categories,proto3" json:"categories,omitempty"`
+	Locale     string   `protobuf:"bytes,9,opt,name=locale,proto3" json:"locale,omitempty"`
+	// Overrides the screen emulation that's derived from form_factor
+	ScreenEmulation *ScreenEmulation `protobuf:"bytes,10,opt,name=screen_emulation,json=screenEmulation,proto3" json:"screen_emulation,omitempty"`
+	// Overrides the user agent that's derived from form_factor
+	UserAgent          string            `protobuf:"bytes,11,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
+	ExtraHeaders       map[string]string `protobuf:"bytes,12,rep,name=extra_headers,json=extraHeaders,proto3" json:"extra_headers,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	BlockedUrlPatterns []string          `protobuf:"bytes,13,rep,name=blocked_url_patterns,json=blockedUrlPatterns,proto3" json:"blocked_url_patterns,omitempty"`
+	// Identifies the run, e.g. in the name of the docker container. A random
+	// suffix is appended, so runs with the same request ID don't collide.
+	RequestId string `protobuf:"bytes,14,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	// Artifacts to return in addition to the lighthouse JSON
+	Artifacts []ArtifactType `protobuf:"varint,15,rep,packed,name=artifacts,proto3,enum=lighthouse.ArtifactType" json:"artifacts,omitempty"`
+	// Lighthouse version to run, e.g. "9.6.0". The default version of the
+	// server is used when empty.
+	LighthouseVersion string `protobuf:"bytes,16,opt,name=lighthouse_version,json=lighthouseVersion,proto3" json:"lighthouse_version,omitempty"`
+	// Runs a user flow with these steps instead of auditing url. The result
+	// is the lighthouse flow result JSON.
+	FlowSteps []*FlowStep `protobuf:"bytes,17,rep,name=flow_steps,json=flowSteps,proto3" json:"flow_steps,omitempty"`
+	// Custom lighthouse config JSON, e.g. with custom audits or plugins. The
+	// typed fields override the settings of the config.
+	ConfigJson string `protobuf:"bytes,18,opt,name=config_json,json=configJson,proto3" json:"config_json,omitempty"`
+	// Logs the details of every gathering and auditing step
+	Verbose bool `protobuf:"varint,19,opt,name=verbose,proto3" json:"verbose,omitempty"`
+	// Runs lighthouse even when the result of an identical request is cached.
+	// The result is still added to the cache.
+	NoCache bool `protobuf:"varint,20,opt,name=no_cache,json=noCache,proto3" json:"no_cache,omitempty"`
+}
+
+func (x *LighthouseRequest) Reset() {
+	*x = LighthouseRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lighthouse_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LighthouseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LighthouseRequest) ProtoMessage() {}
+
+func (x *LighthouseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lighthouse_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LighthouseRequest.ProtoReflect.Descriptor instead.
+func (*LighthouseRequest) Descriptor() ([]byte, []int) {
+	return file_lighthouse_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *LighthouseRequest) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *LighthouseRequest) GetOptions() []string {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+func (x *LighthouseRequest) GetChromeflags() []string {
+	if x != nil {
+		return x.Chromeflags
+	}
+	return nil
+}
+
+func (x *LighthouseRequest) GetResultCompression() Compression {
+	if x != nil {
+		return x.ResultCompression
+	}
+	return Compression_NONE
+}
+
+func (x *LighthouseRequest) GetFormFactor() FormFactor {
+	if x != nil {
+		return x.FormFactor
+	}
+	return FormFactor_FORM_FACTOR_UNSPECIFIED
+}
+
+func (x *LighthouseRequest) GetThrottlingMethod() ThrottlingMethod {
+	if x != nil {
+		return x.ThrottlingMethod
+	}
+	return ThrottlingMethod_THROTTLING_METHOD_UNSPECIFIED
+}
+
+func (x *LighthouseRequest) GetThrottling() *Throttling {
+	if x != nil {
+		return x.Throttling
+	}
+	return nil
+}
+
+func (x *LighthouseRequest) GetCategories() []string {
+	if x != nil {
+		return x.Categories
+	}
+	return nil
+}
+
+func (x *LighthouseRequest) GetLocale() string {
+	if x != nil {
+		return x.Locale
+	}
+	return ""
+}
+
+func (x *LighthouseRequest) GetScreenEmulation() *ScreenEmulation {
+	if x != nil {
+		return x.ScreenEmulation
+	}
+	return nil
+}
+
+func (x *LighthouseRequest) GetUserAgent() string {
+	if x != nil {
+		return x.UserAgent
+	}
+	return ""
+}
+
+func (x *LighthouseRequest) GetExtraHeaders() map[string]string {
+	if x != nil {
+		return x.ExtraHeaders
+	}
+	return nil
+}
+
+func (x *LighthouseRequest) GetBlockedUrlPatterns() []string {
+	if x != nil {
+		return x.BlockedUrlPatterns
+	}
+	return nil
+}
+
+func (x *LighthouseRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *LighthouseRequest) GetArtifacts() []ArtifactType {
+	if x != nil {
+		return x.Artifacts
+	}
+	return nil
+}
+
+func (x *LighthouseRequest) GetLighthouseVersion() string {
+	if x != nil {
+		return x.LighthouseVersion
+	}
+	return ""
+}
+
+func (x *LighthouseRequest) GetFlowSteps() []*FlowStep {
+	if x != nil {
+		return x.FlowSteps
+	}
+	return nil
+}
+
+func (x *LighthouseRequest) GetConfigJson() string {
+	if x != nil {
+		return x.ConfigJson
+	}
+	return ""
+}
+
+func (x *LighthouseRequest) GetVerbose() bool {
+	if x != nil {
+		return x.Verbose
+	}
+	return false
+}
+
+func (x *LighthouseRequest) GetNoCache() bool {
+	if x != nil {
+		return x.NoCache
+	}
+	return false
+}
+
+// Environment describes the host and the conditions of a lighthouse run, so
+// score changes caused by a loaded host can be told apart.
+type Environment struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Number of CPUs of the host
+	CpuCount int32 `protobuf:"varint,1,opt,name=cpu_count,json=cpuCount,proto3" json:"cpu_count,omitempty"`
+	// 1 minute load average of the host when the run started and ended
+	LoadAverageStart float64 `protobuf:"fixed64,2,opt,name=load_average_start,json=loadAverageStart,proto3" json:"load_average_start,omitempty"`
+	LoadAverageEnd   float64 `protobuf:"fixed64,3,opt,name=load_average_end,json=loadAverageEnd,proto3" json:"load_average_end,omitempty"`
+	// Number of runs that were executing on the server when the run started,
+	// including the run itself
+	ConcurrentRuns int32  `protobuf:"varint,4,opt,name=concurrent_runs,json=concurrentRuns,proto3" json:"concurrent_runs,omitempty"`
+	ChromeVersion  string `protobuf:"bytes,5,opt,name=chrome_version,json=chromeVersion,proto3" json:"chrome_version,omitempty"`
+	// CPU benchmark that lighthouse runs on the host
+	BenchmarkIndex float64 `protobuf:"fixed64,6,opt,name=benchmark_index,json=benchmarkIndex,proto3" json:"benchmark_index,omitempty"`
+}
+
+func (x *Environment) Reset() {
+	*x = Environment{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lighthouse_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Environment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Environment) ProtoMessage() {}
+
+func (x *Environment) ProtoReflect() protoreflect.Message {
+	mi := &file_lighthouse_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Environment.ProtoReflect.Descriptor instead.
+func (*Environment) Descriptor() ([]byte, []int) {
+	return file_lighthouse_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Environment) GetCpuCount() int32 {
+	if x != nil {
+		return x.CpuCount
+	}
+	return 0
+}
+
+func (x *Environment) GetLoadAverageStart() float64 {
+	if x != nil {
+		return x.LoadAverageStart
+	}
+	return 0
+}
+
+func (x *Environment) GetLoadAverageEnd() float64 {
+	if x != nil {
+		return x.LoadAverageEnd
+	}
+	return 0
+}
+
+func (x *Environment) GetConcurrentRuns() int32 {
+	if x != nil {
+		return x.ConcurrentRuns
+	}
+	return 0
+}
+
+func (x *Environment) GetChromeVersion() string {
+	if x != nil {
+		return x.ChromeVersion
+	}
+	return ""
+}
+
+func (x *Environment) GetBenchmarkIndex() float64 {
+	if x != nil {
+		return x.BenchmarkIndex
+	}
+	return 0
+}
+
+type LighthouseResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Stdout      []byte       `protobuf:"bytes,1,opt,name=stdout,proto3" json:"stdout,omitempty"`
+	Artifacts   []*Artifact  `protobuf:"bytes,2,rep,name=artifacts,proto3" json:"artifacts,omitempty"`
+	Environment *Environment `protobuf:"bytes,3,opt,name=environment,proto3" json:"environment,omitempty"`
+}
+
+func (x *LighthouseResult) Reset() {
+	*x = LighthouseResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lighthouse_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LighthouseResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LighthouseResult) ProtoMessage() {}
+
+func (x *LighthouseResult) ProtoReflect() protoreflect.Message {
+	mi := &file_lighthouse_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LighthouseResult.ProtoReflect.Descriptor instead.
+func (*LighthouseResult) Descriptor() ([]byte, []int) {
+	return file_lighthouse_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *LighthouseResult) GetStdout() []byte {
+	if x != nil {
+		return x.Stdout
+	}
+	return nil
+}
+
+func (x *LighthouseResult) GetArtifacts() []*Artifact {
+	if x != nil {
+		return x.Artifacts
+	}
+	return nil
+}
+
+func (x *LighthouseResult) GetEnvironment() *Environment {
+	if x != nil {
+		return x.Environment
+	}
+	return nil
+}
+
+type Progress struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Stage   Progress_Stage `protobuf:"varint,1,opt,name=stage,proto3,enum=lighthouse.Progress_Stage" json:"stage,omitempty"`
+	Message string         `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (x *Progress) Reset() {
+	*x = Progress{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lighthouse_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Progress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Progress) ProtoMessage() {}
+
+func (x *Progress) ProtoReflect() protoreflect.Message {
+	mi := &file_lighthouse_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Progress.ProtoReflect.Descriptor instead.
+func (*Progress) Descriptor() ([]byte, []int) {
+	return file_lighthouse_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Progress) GetStage() Progress_Stage {
+	if x != nil {
+		return x.Stage
+	}
+	return Progress_UNKNOWN
+}
+
+func (x *Progress) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ResultChunk struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Data        []byte      `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Compression Compression `protobuf:"varint,2,opt,name=compression,proto3,enum=lighthouse.Compression" json:"compression,omitempty"`
+	// Size in bytes of all chunks together
+	TotalSize int64 `protobuf:"varint,3,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
+	// Set on the final chunk of the result
+	Last bool `protobuf:"varint,4,opt,name=last,proto3" json:"last,omitempty"`
+	// Set when the chunk belongs to an artifact instead of the lighthouse JSON.
+	// The data of the artifact itself is sent in the data field of the chunks.
+	Artifact *Artifact `protobuf:"bytes,5,opt,name=artifact,proto3" json:"artifact,omitempty"`
+}
+
+func (x *ResultChunk) Reset() {
+	*x = ResultChunk{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lighthouse_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ResultChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResultChunk) ProtoMessage() {}
+
+func (x *ResultChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_lighthouse_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResultChunk.ProtoReflect.Descriptor instead.
+func (*ResultChunk) Descriptor() ([]byte, []int) {
+	return file_lighthouse_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ResultChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *ResultChunk) GetCompression() Compression {
+	if x != nil {
+		return x.Compression
+	}
+	return Compression_NONE
+}
+
+func (x *ResultChunk) GetTotalSize() int64 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
+func (x *ResultChunk) GetLast() bool {
+	if x != nil {
+		return x.Last
+	}
+	return false
+}
+
+func (x *ResultChunk) GetArtifact() *Artifact {
+	if x != nil {
+		return x.Artifact
+	}
+	return nil
+}
+
+type RunStreamResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Event:
+	//	*RunStreamResponse_Progress
+	//	*RunStreamResponse_Chunk
+	//	*RunStreamResponse_Environment
+	Event isRunStreamResponse_Event `protobuf_oneof:"event"`
+}
+
+func (x *RunStreamResponse) Reset() {
+	*x = RunStreamResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lighthouse_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RunStreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunStreamResponse) ProtoMessage() {}
+
+func (x *RunStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_lighthouse_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunStreamResponse.ProtoReflect.Descriptor instead.
+func (*RunStreamResponse) Descriptor() ([]byte, []int) {
+	return file_lighthouse_proto_rawDescGZIP(), []int{9}
+}
+
+func (m *RunStreamResponse) GetEvent() isRunStreamResponse_Event {
+	if m != nil {
+		return m.Event
+	}
+	return nil
+}
+
+func (x *RunStreamResponse) GetProgress() *Progress {
+	if x, ok := x.GetEvent().(*RunStreamResponse_Progress); ok {
+		return x.Progress
+	}
+	return nil
+}
+
+func (x *RunStreamResponse) GetChunk() *ResultChunk {
+	if x, ok := x.GetEvent().(*RunStreamResponse_Chunk); ok {
+		return x.Chunk
+	}
+	return nil
+}
+
+func (x *RunStreamResponse) GetEnvironment() *Environment {
+	if x, ok := x.GetEvent().(*RunStreamResponse_Environment); ok {
+		return x.Environment
+	}
+	return nil
+}
+
+type isRunStreamResponse_Event interface {
+	isRunStreamResponse_Event()
+}
+
+type RunStreamResponse_Progress struct {
+	Progress *Progress `protobuf:"bytes,1,opt,name=progress,proto3,oneof"`
+}
+
+type RunStreamResponse_Chunk struct {
+	Chunk *ResultChunk `protobuf:"bytes,2,opt,name=chunk,proto3,oneof"`
+}
+
+type RunStreamResponse_Environment struct {
+	// Sent before the chunks of the lighthouse JSON
+	Environment *Environment `protobuf:"bytes,3,opt,name=environment,proto3,oneof"`
+}
+
+func (*RunStreamResponse_Progress) isRunStreamResponse_Event() {}
+
+func (*RunStreamResponse_Chunk) isRunStreamResponse_Event() {}
+
+func (*RunStreamResponse_Environment) isRunStreamResponse_Event() {}
+
+type StatusRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *StatusRequest) Reset() {
+	*x = StatusRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lighthouse_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
 
-func (x *LighthouseRequest) Reset() {
-	*x = LighthouseRequest{}
+func (x *StatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusRequest) ProtoMessage() {}
+
+func (x *StatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lighthouse_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return file_lighthouse_proto_rawDescGZIP(), []int{10}
+}
+
+type ChromePoolStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Number of Chrome instances kept alive
+	Size int32 `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+	Idle int32 `protobuf:"varint,2,opt,name=idle,proto3" json:"idle,omitempty"`
+	Busy int32 `protobuf:"varint,3,opt,name=busy,proto3" json:"busy,omitempty"`
+	// Number of runs that used the pool
+	Runs int64 `protobuf:"varint,4,opt,name=runs,proto3" json:"runs,omitempty"`
+	// Number of instances that were replaced after crashing or reaching the
+	// maximum number of runs
+	Restarts int64 `protobuf:"varint,5,opt,name=restarts,proto3" json:"restarts,omitempty"`
+	Crashes  int64 `protobuf:"varint,6,opt,name=crashes,proto3" json:"crashes,omitempty"`
+}
+
+func (x *ChromePoolStatus) Reset() {
+	*x = ChromePoolStatus{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_lighthouse_proto_msgTypes[0]
+		mi := &file_lighthouse_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *LighthouseRequest) String() string {
+func (x *ChromePoolStatus) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LighthouseRequest) ProtoMessage() {}
+func (*ChromePoolStatus) ProtoMessage() {}
 
-func (x *LighthouseRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_lighthouse_proto_msgTypes[0]
+func (x *ChromePoolStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_lighthouse_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -62,57 +1388,92 @@ func (x *LighthouseRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LighthouseRequest.ProtoReflect.Descriptor instead.
-func (*LighthouseRequest) Descriptor() ([]byte, []int) {
-	return file_lighthouse_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use ChromePoolStatus.ProtoReflect.Descriptor instead.
+func (*ChromePoolStatus) Descriptor() ([]byte, []int) {
+	return file_lighthouse_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *LighthouseRequest) GetUrl() string {
+func (x *ChromePoolStatus) GetSize() int32 {
 	if x != nil {
-		return x.Url
+		return x.Size
 	}
-	return ""
+	return 0
 }
 
-func (x *LighthouseRequest) GetOptions() []string {
+func (x *ChromePoolStatus) GetIdle() int32 {
 	if x != nil {
-		return x.Options
+		return x.Idle
 	}
-	return nil
+	return 0
 }
 
-func (x *LighthouseRequest) GetChromeflags() []string {
+func (x *ChromePoolStatus) GetBusy() int32 {
 	if x != nil {
-		return x.Chromeflags
+		return x.Busy
 	}
-	return nil
+	return 0
 }
 
-type LighthouseResult struct {
+func (x *ChromePoolStatus) GetRuns() int64 {
+	if x != nil {
+		return x.Runs
+	}
+	return 0
+}
+
+func (x *ChromePoolStatus) GetRestarts() int64 {
+	if x != nil {
+		return x.Restarts
+	}
+	return 0
+}
+
+func (x *ChromePoolStatus) GetCrashes() int64 {
+	if x != nil {
+		return x.Crashes
+	}
+	return 0
+}
+
+type StatusResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Stdout []byte `protobuf:"bytes,1,opt,name=stdout,proto3" json:"stdout,omitempty"`
+	// Number of lighthouse runs that are currently executing
+	Running int32 `protobuf:"varint,1,opt,name=running,proto3" json:"running,omitempty"`
+	// Number of lighthouse runs waiting for a free slot
+	Queued int32 `protobuf:"varint,2,opt,name=queued,proto3" json:"queued,omitempty"`
+	// Maximum number of concurrent runs, 0 means unlimited
+	MaxConcurrentRuns int32 `protobuf:"varint,3,opt,name=max_concurrent_runs,json=maxConcurrentRuns,proto3" json:"max_concurrent_runs,omitempty"`
+	// Maximum number of runs that can wait for a free slot
+	MaxQueuedRuns int32 `protobuf:"varint,4,opt,name=max_queued_runs,json=maxQueuedRuns,proto3" json:"max_queued_runs,omitempty"`
+	// Lighthouse versions that can be requested
+	LighthouseVersions []string `protobuf:"bytes,5,rep,name=lighthouse_versions,json=lighthouseVersions,proto3" json:"lighthouse_versions,omitempty"`
+	// Lighthouse version used when a request doesn't specify one
+	DefaultLighthouseVersion string `protobuf:"bytes,6,opt,name=default_lighthouse_version,json=defaultLighthouseVersion,proto3" json:"default_lighthouse_version,omitempty"`
+	// Set when lighthouse-server runs lighthouse against a pool of Chrome
+	// instances
+	ChromePool *ChromePoolStatus `protobuf:"bytes,7,opt,name=chrome_pool,json=chromePool,proto3" json:"chrome_pool,omitempty"`
 }
 
-func (x *LighthouseResult) Reset() {
-	*x = LighthouseResult{}
+func (x *StatusResponse) Reset() {
+	*x = StatusResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_lighthouse_proto_msgTypes[1]
+		mi := &file_lighthouse_proto_msgTypes[12]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *LighthouseResult) String() string {
+func (x *StatusResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LighthouseResult) ProtoMessage() {}
+func (*StatusResponse) ProtoMessage() {}
 
-func (x *LighthouseResult) ProtoReflect() protoreflect.Message {
-	mi := &file_lighthouse_proto_msgTypes[1]
+func (x *StatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_lighthouse_proto_msgTypes[12]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -123,14 +1484,56 @@ func (x *LighthouseResult) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LighthouseResult.ProtoReflect.Descriptor instead.
-func (*LighthouseResult) Descriptor() ([]byte, []int) {
-	return file_lighthouse_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
+func (*StatusResponse) Descriptor() ([]byte, []int) {
+	return file_lighthouse_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *LighthouseResult) GetStdout() []byte {
+func (x *StatusResponse) GetRunning() int32 {
 	if x != nil {
-		return x.Stdout
+		return x.Running
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetQueued() int32 {
+	if x != nil {
+		return x.Queued
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetMaxConcurrentRuns() int32 {
+	if x != nil {
+		return x.MaxConcurrentRuns
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetMaxQueuedRuns() int32 {
+	if x != nil {
+		return x.MaxQueuedRuns
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetLighthouseVersions() []string {
+	if x != nil {
+		return x.LighthouseVersions
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetDefaultLighthouseVersion() string {
+	if x != nil {
+		return x.DefaultLighthouseVersion
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetChromePool() *ChromePoolStatus {
+	if x != nil {
+		return x.ChromePool
 	}
 	return nil
 }
@@ -139,25 +1542,260 @@ var File_lighthouse_proto protoreflect.FileDescriptor
 
 var file_lighthouse_proto_rawDesc = []byte{
 	0x0a, 0x10, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x12, 0x0a, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x22, 0x61,
-	0x0a, 0x11, 0x4c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x72, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x03, 0x75, 0x72, 0x6c, 0x12, 0x18, 0x0a, 0x07, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73,
-	0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12,
-	0x20, 0x0a, 0x0b, 0x63, 0x68, 0x72, 0x6f, 0x6d, 0x65, 0x66, 0x6c, 0x61, 0x67, 0x73, 0x18, 0x03,
-	0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x63, 0x68, 0x72, 0x6f, 0x6d, 0x65, 0x66, 0x6c, 0x61, 0x67,
-	0x73, 0x22, 0x2a, 0x0a, 0x10, 0x4c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x52,
-	0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x64, 0x6f, 0x75, 0x74, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x73, 0x74, 0x64, 0x6f, 0x75, 0x74, 0x32, 0x59, 0x0a,
-	0x11, 0x4c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69,
-	0x63, 0x65, 0x12, 0x44, 0x0a, 0x03, 0x52, 0x75, 0x6e, 0x12, 0x1d, 0x2e, 0x6c, 0x69, 0x67, 0x68,
-	0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x2e, 0x4c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73,
-	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x6c, 0x69, 0x67, 0x68, 0x74,
-	0x68, 0x6f, 0x75, 0x73, 0x65, 0x2e, 0x4c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65,
-	0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x00, 0x42, 0x2a, 0x5a, 0x28, 0x67, 0x69, 0x74, 0x68,
-	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x77, 0x65, 0x62, 0x73, 0x75, 0x2d, 0x69, 0x6f, 0x2f,
-	0x77, 0x65, 0x62, 0x73, 0x75, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x68,
-	0x6f, 0x75, 0x73, 0x65, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x74, 0x6f, 0x12, 0x0a, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x22, 0xa2,
+	0x02, 0x0a, 0x0a, 0x54, 0x68, 0x72, 0x6f, 0x74, 0x74, 0x6c, 0x69, 0x6e, 0x67, 0x12, 0x15, 0x0a,
+	0x06, 0x72, 0x74, 0x74, 0x5f, 0x6d, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x72,
+	0x74, 0x74, 0x4d, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x74, 0x68, 0x72, 0x6f, 0x75, 0x67, 0x68, 0x70,
+	0x75, 0x74, 0x5f, 0x6b, 0x62, 0x70, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0e, 0x74,
+	0x68, 0x72, 0x6f, 0x75, 0x67, 0x68, 0x70, 0x75, 0x74, 0x4b, 0x62, 0x70, 0x73, 0x12, 0x2c, 0x0a,
+	0x12, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x6c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79,
+	0x5f, 0x6d, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x10, 0x72, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x4c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x4d, 0x73, 0x12, 0x38, 0x0a, 0x18, 0x64,
+	0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x74, 0x68, 0x72, 0x6f, 0x75, 0x67, 0x68, 0x70,
+	0x75, 0x74, 0x5f, 0x6b, 0x62, 0x70, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x16, 0x64,
+	0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x54, 0x68, 0x72, 0x6f, 0x75, 0x67, 0x68, 0x70, 0x75,
+	0x74, 0x4b, 0x62, 0x70, 0x73, 0x12, 0x34, 0x0a, 0x16, 0x75, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x5f,
+	0x74, 0x68, 0x72, 0x6f, 0x75, 0x67, 0x68, 0x70, 0x75, 0x74, 0x5f, 0x6b, 0x62, 0x70, 0x73, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x14, 0x75, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x54, 0x68, 0x72,
+	0x6f, 0x75, 0x67, 0x68, 0x70, 0x75, 0x74, 0x4b, 0x62, 0x70, 0x73, 0x12, 0x36, 0x0a, 0x17, 0x63,
+	0x70, 0x75, 0x5f, 0x73, 0x6c, 0x6f, 0x77, 0x64, 0x6f, 0x77, 0x6e, 0x5f, 0x6d, 0x75, 0x6c, 0x74,
+	0x69, 0x70, 0x6c, 0x69, 0x65, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x01, 0x52, 0x15, 0x63, 0x70,
+	0x75, 0x53, 0x6c, 0x6f, 0x77, 0x64, 0x6f, 0x77, 0x6e, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x70, 0x6c,
+	0x69, 0x65, 0x72, 0x22, 0x9b, 0x01, 0x0a, 0x08, 0x41, 0x72, 0x74, 0x69, 0x66, 0x61, 0x63, 0x74,
+	0x12, 0x2c, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x18,
+	0x2e, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x2e, 0x41, 0x72, 0x74, 0x69,
+	0x66, 0x61, 0x63, 0x74, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x12,
+	0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61,
+	0x6d, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x5f, 0x74, 0x79,
+	0x70, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e,
+	0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x16, 0x0a, 0x06, 0x74, 0x69, 0x6d,
+	0x69, 0x6e, 0x67, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x74, 0x69, 0x6d, 0x69, 0x6e,
+	0x67, 0x22, 0xa3, 0x01, 0x0a, 0x0f, 0x53, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x45, 0x6d, 0x75, 0x6c,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x6d, 0x6f, 0x62, 0x69, 0x6c, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6d, 0x6f, 0x62, 0x69, 0x6c, 0x65, 0x12, 0x14, 0x0a,
+	0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x77, 0x69,
+	0x64, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x2e, 0x0a, 0x13, 0x64,
+	0x65, 0x76, 0x69, 0x63, 0x65, 0x5f, 0x73, 0x63, 0x61, 0x6c, 0x65, 0x5f, 0x66, 0x61, 0x63, 0x74,
+	0x6f, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x11, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65,
+	0x53, 0x63, 0x61, 0x6c, 0x65, 0x46, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x64,
+	0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x64,
+	0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x22, 0xbb, 0x02, 0x0a, 0x08, 0x46, 0x6c, 0x6f, 0x77,
+	0x53, 0x74, 0x65, 0x70, 0x12, 0x2d, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0e, 0x32, 0x19, 0x2e, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x2e,
+	0x46, 0x6c, 0x6f, 0x77, 0x53, 0x74, 0x65, 0x70, 0x2e, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74,
+	0x79, 0x70, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x72, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x75, 0x72, 0x6c, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f,
+	0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f,
+	0x72, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x69, 0x6d,
+	0x65, 0x6f, 0x75, 0x74, 0x5f, 0x6d, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x74,
+	0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x4d, 0x73, 0x22, 0x8a, 0x01, 0x0a, 0x04, 0x54, 0x79, 0x70,
+	0x65, 0x12, 0x14, 0x0a, 0x10, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43,
+	0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0c, 0x0a, 0x08, 0x4e, 0x41, 0x56, 0x49, 0x47,
+	0x41, 0x54, 0x45, 0x10, 0x01, 0x12, 0x09, 0x0a, 0x05, 0x43, 0x4c, 0x49, 0x43, 0x4b, 0x10, 0x02,
+	0x12, 0x08, 0x0a, 0x04, 0x54, 0x59, 0x50, 0x45, 0x10, 0x03, 0x12, 0x15, 0x0a, 0x11, 0x57, 0x41,
+	0x49, 0x54, 0x5f, 0x46, 0x4f, 0x52, 0x5f, 0x53, 0x45, 0x4c, 0x45, 0x43, 0x54, 0x4f, 0x52, 0x10,
+	0x04, 0x12, 0x12, 0x0a, 0x0e, 0x53, 0x54, 0x41, 0x52, 0x54, 0x5f, 0x54, 0x49, 0x4d, 0x45, 0x53,
+	0x50, 0x41, 0x4e, 0x10, 0x05, 0x12, 0x10, 0x0a, 0x0c, 0x45, 0x4e, 0x44, 0x5f, 0x54, 0x49, 0x4d,
+	0x45, 0x53, 0x50, 0x41, 0x4e, 0x10, 0x06, 0x12, 0x0c, 0x0a, 0x08, 0x53, 0x4e, 0x41, 0x50, 0x53,
+	0x48, 0x4f, 0x54, 0x10, 0x07, 0x22, 0xde, 0x07, 0x0a, 0x11, 0x4c, 0x69, 0x67, 0x68, 0x74, 0x68,
+	0x6f, 0x75, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x75,
+	0x72, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x72, 0x6c, 0x12, 0x18, 0x0a,
+	0x07, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07,
+	0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x20, 0x0a, 0x0b, 0x63, 0x68, 0x72, 0x6f, 0x6d,
+	0x65, 0x66, 0x6c, 0x61, 0x67, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x63, 0x68,
+	0x72, 0x6f, 0x6d, 0x65, 0x66, 0x6c, 0x61, 0x67, 0x73, 0x12, 0x46, 0x0a, 0x12, 0x72, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x5f, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x17, 0x2e, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75,
+	0x73, 0x65, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x11,
+	0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x37, 0x0a, 0x0b, 0x66, 0x6f, 0x72, 0x6d, 0x5f, 0x66, 0x61, 0x63, 0x74, 0x6f, 0x72,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x16, 0x2e, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f,
+	0x75, 0x73, 0x65, 0x2e, 0x46, 0x6f, 0x72, 0x6d, 0x46, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x52, 0x0a,
+	0x66, 0x6f, 0x72, 0x6d, 0x46, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x49, 0x0a, 0x11, 0x74, 0x68,
+	0x72, 0x6f, 0x74, 0x74, 0x6c, 0x69, 0x6e, 0x67, 0x5f, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1c, 0x2e, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75,
+	0x73, 0x65, 0x2e, 0x54, 0x68, 0x72, 0x6f, 0x74, 0x74, 0x6c, 0x69, 0x6e, 0x67, 0x4d, 0x65, 0x74,
+	0x68, 0x6f, 0x64, 0x52, 0x10, 0x74, 0x68, 0x72, 0x6f, 0x74, 0x74, 0x6c, 0x69, 0x6e, 0x67, 0x4d,
+	0x65, 0x74, 0x68, 0x6f, 0x64, 0x12, 0x36, 0x0a, 0x0a, 0x74, 0x68, 0x72, 0x6f, 0x74, 0x74, 0x6c,
+	0x69, 0x6e, 0x67, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x6c, 0x69, 0x67, 0x68,
+	0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x2e, 0x54, 0x68, 0x72, 0x6f, 0x74, 0x74, 0x6c, 0x69, 0x6e,
+	0x67, 0x52, 0x0a, 0x74, 0x68, 0x72, 0x6f, 0x74, 0x74, 0x6c, 0x69, 0x6e, 0x67, 0x12, 0x1e, 0x0a,
+	0x0a, 0x63, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x0a, 0x63, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x12, 0x16, 0x0a,
+	0x06, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6c,
+	0x6f, 0x63, 0x61, 0x6c, 0x65, 0x12, 0x46, 0x0a, 0x10, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x5f,
+	0x65, 0x6d, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1b, 0x2e, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x2e, 0x53, 0x63, 0x72,
+	0x65, 0x65, 0x6e, 0x45, 0x6d, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0f, 0x73, 0x63,
+	0x72, 0x65, 0x65, 0x6e, 0x45, 0x6d, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1d, 0x0a,
+	0x0a, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x18, 0x0b, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x75, 0x73, 0x65, 0x72, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x12, 0x54, 0x0a, 0x0d,
+	0x65, 0x78, 0x74, 0x72, 0x61, 0x5f, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x18, 0x0c, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x2f, 0x2e, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65,
+	0x2e, 0x4c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x2e, 0x45, 0x78, 0x74, 0x72, 0x61, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x52, 0x0c, 0x65, 0x78, 0x74, 0x72, 0x61, 0x48, 0x65, 0x61, 0x64, 0x65,
+	0x72, 0x73, 0x12, 0x30, 0x0a, 0x14, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x5f, 0x75, 0x72,
+	0x6c, 0x5f, 0x70, 0x61, 0x74, 0x74, 0x65, 0x72, 0x6e, 0x73, 0x18, 0x0d, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x12, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x55, 0x72, 0x6c, 0x50, 0x61, 0x74, 0x74,
+	0x65, 0x72, 0x6e, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f,
+	0x69, 0x64, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x49, 0x64, 0x12, 0x36, 0x0a, 0x09, 0x61, 0x72, 0x74, 0x69, 0x66, 0x61, 0x63, 0x74, 0x73,
+	0x18, 0x0f, 0x20, 0x03, 0x28, 0x0e, 0x32, 0x18, 0x2e, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f,
+	0x75, 0x73, 0x65, 0x2e, 0x41, 0x72, 0x74, 0x69, 0x66, 0x61, 0x63, 0x74, 0x54, 0x79, 0x70, 0x65,
+	0x52, 0x09, 0x61, 0x72, 0x74, 0x69, 0x66, 0x61, 0x63, 0x74, 0x73, 0x12, 0x2d, 0x0a, 0x12, 0x6c,
+	0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x18, 0x10, 0x20, 0x01, 0x28, 0x09, 0x52, 0x11, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f,
+	0x75, 0x73, 0x65, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x33, 0x0a, 0x0a, 0x66, 0x6c,
+	0x6f, 0x77, 0x5f, 0x73, 0x74, 0x65, 0x70, 0x73, 0x18, 0x11, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14,
+	0x2e, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x2e, 0x46, 0x6c, 0x6f, 0x77,
+	0x53, 0x74, 0x65, 0x70, 0x52, 0x09, 0x66, 0x6c, 0x6f, 0x77, 0x53, 0x74, 0x65, 0x70, 0x73, 0x12,
+	0x1f, 0x0a, 0x0b, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x5f, 0x6a, 0x73, 0x6f, 0x6e, 0x18, 0x12,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x4a, 0x73, 0x6f, 0x6e,
+	0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x62, 0x6f, 0x73, 0x65, 0x18, 0x13, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x07, 0x76, 0x65, 0x72, 0x62, 0x6f, 0x73, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x6e, 0x6f,
+	0x5f, 0x63, 0x61, 0x63, 0x68, 0x65, 0x18, 0x14, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x6e, 0x6f,
+	0x43, 0x61, 0x63, 0x68, 0x65, 0x1a, 0x3f, 0x0a, 0x11, 0x45, 0x78, 0x74, 0x72, 0x61, 0x48, 0x65,
+	0x61, 0x64, 0x65, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65,
+	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xfb, 0x01, 0x0a, 0x0b, 0x45, 0x6e, 0x76, 0x69, 0x72,
+	0x6f, 0x6e, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x63, 0x70, 0x75, 0x5f, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x63, 0x70, 0x75, 0x43, 0x6f,
+	0x75, 0x6e, 0x74, 0x12, 0x2c, 0x0a, 0x12, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x61, 0x76, 0x65, 0x72,
+	0x61, 0x67, 0x65, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x10, 0x6c, 0x6f, 0x61, 0x64, 0x41, 0x76, 0x65, 0x72, 0x61, 0x67, 0x65, 0x53, 0x74, 0x61, 0x72,
+	0x74, 0x12, 0x28, 0x0a, 0x10, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x61, 0x76, 0x65, 0x72, 0x61, 0x67,
+	0x65, 0x5f, 0x65, 0x6e, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0e, 0x6c, 0x6f, 0x61,
+	0x64, 0x41, 0x76, 0x65, 0x72, 0x61, 0x67, 0x65, 0x45, 0x6e, 0x64, 0x12, 0x27, 0x0a, 0x0f, 0x63,
+	0x6f, 0x6e, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x5f, 0x72, 0x75, 0x6e, 0x73, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x0e, 0x63, 0x6f, 0x6e, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74,
+	0x52, 0x75, 0x6e, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x63, 0x68, 0x72, 0x6f, 0x6d, 0x65, 0x5f, 0x76,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x63, 0x68,
+	0x72, 0x6f, 0x6d, 0x65, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x27, 0x0a, 0x0f, 0x62,
+	0x65, 0x6e, 0x63, 0x68, 0x6d, 0x61, 0x72, 0x6b, 0x5f, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x0e, 0x62, 0x65, 0x6e, 0x63, 0x68, 0x6d, 0x61, 0x72, 0x6b, 0x49,
+	0x6e, 0x64, 0x65, 0x78, 0x22, 0x99, 0x01, 0x0a, 0x10, 0x4c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f,
+	0x75, 0x73, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x64,
+	0x6f, 0x75, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x73, 0x74, 0x64, 0x6f, 0x75,
+	0x74, 0x12, 0x32, 0x0a, 0x09, 0x61, 0x72, 0x74, 0x69, 0x66, 0x61, 0x63, 0x74, 0x73, 0x18, 0x02,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73,
+	0x65, 0x2e, 0x41, 0x72, 0x74, 0x69, 0x66, 0x61, 0x63, 0x74, 0x52, 0x09, 0x61, 0x72, 0x74, 0x69,
+	0x66, 0x61, 0x63, 0x74, 0x73, 0x12, 0x39, 0x0a, 0x0b, 0x65, 0x6e, 0x76, 0x69, 0x72, 0x6f, 0x6e,
+	0x6d, 0x65, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x6c, 0x69, 0x67,
+	0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x2e, 0x45, 0x6e, 0x76, 0x69, 0x72, 0x6f, 0x6e, 0x6d,
+	0x65, 0x6e, 0x74, 0x52, 0x0b, 0x65, 0x6e, 0x76, 0x69, 0x72, 0x6f, 0x6e, 0x6d, 0x65, 0x6e, 0x74,
+	0x22, 0xb1, 0x01, 0x0a, 0x08, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x12, 0x30, 0x0a,
+	0x05, 0x73, 0x74, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1a, 0x2e, 0x6c,
+	0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x2e, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x65,
+	0x73, 0x73, 0x2e, 0x53, 0x74, 0x61, 0x67, 0x65, 0x52, 0x05, 0x73, 0x74, 0x61, 0x67, 0x65, 0x12,
+	0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x59, 0x0a, 0x05, 0x53, 0x74, 0x61,
+	0x67, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12,
+	0x0a, 0x0a, 0x06, 0x51, 0x55, 0x45, 0x55, 0x45, 0x44, 0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07, 0x53,
+	0x54, 0x41, 0x52, 0x54, 0x45, 0x44, 0x10, 0x02, 0x12, 0x0d, 0x0a, 0x09, 0x47, 0x41, 0x54, 0x48,
+	0x45, 0x52, 0x49, 0x4e, 0x47, 0x10, 0x03, 0x12, 0x0c, 0x0a, 0x08, 0x41, 0x55, 0x44, 0x49, 0x54,
+	0x49, 0x4e, 0x47, 0x10, 0x04, 0x12, 0x0d, 0x0a, 0x09, 0x43, 0x4f, 0x4d, 0x50, 0x4c, 0x45, 0x54,
+	0x45, 0x44, 0x10, 0x05, 0x22, 0xc1, 0x01, 0x0a, 0x0b, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x43,
+	0x68, 0x75, 0x6e, 0x6b, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x39, 0x0a, 0x0b, 0x63, 0x6f, 0x6d, 0x70,
+	0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x17, 0x2e,
+	0x6c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x72,
+	0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x73, 0x69, 0x7a,
+	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x53, 0x69,
+	0x7a, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x61, 0x73, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x04, 0x6c, 0x61, 0x73, 0x74, 0x12, 0x30, 0x0a, 0x08, 0x61, 0x72, 0x74, 0x69, 0x66, 0x61,
+	0x63, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x6c, 0x69, 0x67, 0x68, 0x74,
+	0x68, 0x6f, 0x75, 0x73, 0x65, 0x2e, 0x41, 0x72, 0x74, 0x69, 0x66, 0x61, 0x63, 0x74, 0x52, 0x08,
+	0x61, 0x72, 0x74, 0x69, 0x66, 0x61, 0x63, 0x74, 0x22, 0xbe, 0x01, 0x0a, 0x11, 0x52, 0x75, 0x6e,
+	0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x32,
+	0x0a, 0x08, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x14, 0x2e, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x2e, 0x50, 0x72,
+	0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x48, 0x00, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x65,
+	0x73, 0x73, 0x12, 0x2f, 0x0a, 0x05, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x17, 0x2e, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x2e, 0x52,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x48, 0x00, 0x52, 0x05, 0x63, 0x68,
+	0x75, 0x6e, 0x6b, 0x12, 0x3b, 0x0a, 0x0b, 0x65, 0x6e, 0x76, 0x69, 0x72, 0x6f, 0x6e, 0x6d, 0x65,
+	0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x6c, 0x69, 0x67, 0x68, 0x74,
+	0x68, 0x6f, 0x75, 0x73, 0x65, 0x2e, 0x45, 0x6e, 0x76, 0x69, 0x72, 0x6f, 0x6e, 0x6d, 0x65, 0x6e,
+	0x74, 0x48, 0x00, 0x52, 0x0b, 0x65, 0x6e, 0x76, 0x69, 0x72, 0x6f, 0x6e, 0x6d, 0x65, 0x6e, 0x74,
+	0x42, 0x07, 0x0a, 0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x22, 0x0f, 0x0a, 0x0d, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x98, 0x01, 0x0a, 0x10, 0x43,
+	0x68, 0x72, 0x6f, 0x6d, 0x65, 0x50, 0x6f, 0x6f, 0x6c, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12,
+	0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x73,
+	0x69, 0x7a, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x69, 0x64, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x04, 0x69, 0x64, 0x6c, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x62, 0x75, 0x73, 0x79, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x62, 0x75, 0x73, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x72,
+	0x75, 0x6e, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x72, 0x75, 0x6e, 0x73, 0x12,
+	0x1a, 0x0a, 0x08, 0x72, 0x65, 0x73, 0x74, 0x61, 0x72, 0x74, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x08, 0x72, 0x65, 0x73, 0x74, 0x61, 0x72, 0x74, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x63,
+	0x72, 0x61, 0x73, 0x68, 0x65, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x63, 0x72,
+	0x61, 0x73, 0x68, 0x65, 0x73, 0x22, 0xc8, 0x02, 0x0a, 0x0e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x75, 0x6e, 0x6e,
+	0x69, 0x6e, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x72, 0x75, 0x6e, 0x6e, 0x69,
+	0x6e, 0x67, 0x12, 0x16, 0x0a, 0x06, 0x71, 0x75, 0x65, 0x75, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x06, 0x71, 0x75, 0x65, 0x75, 0x65, 0x64, 0x12, 0x2e, 0x0a, 0x13, 0x6d, 0x61,
+	0x78, 0x5f, 0x63, 0x6f, 0x6e, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x5f, 0x72, 0x75, 0x6e,
+	0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x11, 0x6d, 0x61, 0x78, 0x43, 0x6f, 0x6e, 0x63,
+	0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x52, 0x75, 0x6e, 0x73, 0x12, 0x26, 0x0a, 0x0f, 0x6d, 0x61,
+	0x78, 0x5f, 0x71, 0x75, 0x65, 0x75, 0x65, 0x64, 0x5f, 0x72, 0x75, 0x6e, 0x73, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x0d, 0x6d, 0x61, 0x78, 0x51, 0x75, 0x65, 0x75, 0x65, 0x64, 0x52, 0x75,
+	0x6e, 0x73, 0x12, 0x2f, 0x0a, 0x13, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65,
+	0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x12, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x56, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x73, 0x12, 0x3c, 0x0a, 0x1a, 0x64, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x5f, 0x6c,
+	0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x18, 0x64, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74,
+	0x4c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x3d, 0x0a, 0x0b, 0x63, 0x68, 0x72, 0x6f, 0x6d, 0x65, 0x5f, 0x70, 0x6f, 0x6f, 0x6c,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f,
+	0x75, 0x73, 0x65, 0x2e, 0x43, 0x68, 0x72, 0x6f, 0x6d, 0x65, 0x50, 0x6f, 0x6f, 0x6c, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x52, 0x0a, 0x63, 0x68, 0x72, 0x6f, 0x6d, 0x65, 0x50, 0x6f, 0x6f, 0x6c,
+	0x2a, 0x21, 0x0a, 0x0b, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12,
+	0x08, 0x0a, 0x04, 0x4e, 0x4f, 0x4e, 0x45, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x47, 0x5a, 0x49,
+	0x50, 0x10, 0x01, 0x2a, 0x42, 0x0a, 0x0a, 0x46, 0x6f, 0x72, 0x6d, 0x46, 0x61, 0x63, 0x74, 0x6f,
+	0x72, 0x12, 0x1b, 0x0a, 0x17, 0x46, 0x4f, 0x52, 0x4d, 0x5f, 0x46, 0x41, 0x43, 0x54, 0x4f, 0x52,
+	0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0b,
+	0x0a, 0x07, 0x44, 0x45, 0x53, 0x4b, 0x54, 0x4f, 0x50, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x4d,
+	0x4f, 0x42, 0x49, 0x4c, 0x45, 0x10, 0x02, 0x2a, 0x5f, 0x0a, 0x10, 0x54, 0x68, 0x72, 0x6f, 0x74,
+	0x74, 0x6c, 0x69, 0x6e, 0x67, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x12, 0x21, 0x0a, 0x1d, 0x54,
+	0x48, 0x52, 0x4f, 0x54, 0x54, 0x4c, 0x49, 0x4e, 0x47, 0x5f, 0x4d, 0x45, 0x54, 0x48, 0x4f, 0x44,
+	0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0c,
+	0x0a, 0x08, 0x53, 0x49, 0x4d, 0x55, 0x4c, 0x41, 0x54, 0x45, 0x10, 0x01, 0x12, 0x0c, 0x0a, 0x08,
+	0x44, 0x45, 0x56, 0x54, 0x4f, 0x4f, 0x4c, 0x53, 0x10, 0x02, 0x12, 0x0c, 0x0a, 0x08, 0x50, 0x52,
+	0x4f, 0x56, 0x49, 0x44, 0x45, 0x44, 0x10, 0x03, 0x2a, 0x84, 0x01, 0x0a, 0x0c, 0x41, 0x72, 0x74,
+	0x69, 0x66, 0x61, 0x63, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1d, 0x0a, 0x19, 0x41, 0x52, 0x54,
+	0x49, 0x46, 0x41, 0x43, 0x54, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45,
+	0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0f, 0x0a, 0x0b, 0x48, 0x54, 0x4d, 0x4c,
+	0x5f, 0x52, 0x45, 0x50, 0x4f, 0x52, 0x54, 0x10, 0x01, 0x12, 0x14, 0x0a, 0x10, 0x46, 0x49, 0x4e,
+	0x41, 0x4c, 0x5f, 0x53, 0x43, 0x52, 0x45, 0x45, 0x4e, 0x53, 0x48, 0x4f, 0x54, 0x10, 0x02, 0x12,
+	0x19, 0x0a, 0x15, 0x53, 0x43, 0x52, 0x45, 0x45, 0x4e, 0x53, 0x48, 0x4f, 0x54, 0x5f, 0x54, 0x48,
+	0x55, 0x4d, 0x42, 0x4e, 0x41, 0x49, 0x4c, 0x53, 0x10, 0x03, 0x12, 0x09, 0x0a, 0x05, 0x54, 0x52,
+	0x41, 0x43, 0x45, 0x10, 0x04, 0x12, 0x08, 0x0a, 0x04, 0x4c, 0x4f, 0x47, 0x53, 0x10, 0x05, 0x32,
+	0xeb, 0x01, 0x0a, 0x11, 0x4c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x53, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x44, 0x0a, 0x03, 0x52, 0x75, 0x6e, 0x12, 0x1d, 0x2e, 0x6c,
+	0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x2e, 0x4c, 0x69, 0x67, 0x68, 0x74, 0x68,
+	0x6f, 0x75, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x6c, 0x69,
+	0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x2e, 0x4c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f,
+	0x75, 0x73, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x00, 0x12, 0x41, 0x0a, 0x06, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x19, 0x2e, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75,
+	0x73, 0x65, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x1a, 0x2e, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x2e, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x4d,
+	0x0a, 0x09, 0x52, 0x75, 0x6e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x1d, 0x2e, 0x6c, 0x69,
+	0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x2e, 0x4c, 0x69, 0x67, 0x68, 0x74, 0x68, 0x6f,
+	0x75, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x6c, 0x69, 0x67,
+	0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x2e, 0x52, 0x75, 0x6e, 0x53, 0x74, 0x72, 0x65, 0x61,
+	0x6d, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x30, 0x01, 0x42, 0x2a, 0x5a,
+	0x28, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x77, 0x65, 0x62, 0x73,
+	0x75, 0x2d, 0x69, 0x6f, 0x2f, 0x77, 0x65, 0x62, 0x73, 0x75, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x6c,
+	0x69, 0x67, 0x68, 0x74, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
 }
 
 var (
@@ -172,19 +1810,61 @@ func file_lighthouse_proto_rawDescGZIP() []byte {
 	return file_lighthouse_proto_rawDescData
 }
 
-var file_lighthouse_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_lighthouse_proto_enumTypes = make([]protoimpl.EnumInfo, 6)
+var file_lighthouse_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
 var file_lighthouse_proto_goTypes = []interface{}{
-	(*LighthouseRequest)(nil), // 0: lighthouse.LighthouseRequest
-	(*LighthouseResult)(nil),  // 1: lighthouse.LighthouseResult
+	(Compression)(0),          // 0: lighthouse.Compression
+	(FormFactor)(0),           // 1: lighthouse.FormFactor
+	(ThrottlingMethod)(0),     // 2: lighthouse.ThrottlingMethod
+	(ArtifactType)(0),         // 3: lighthouse.ArtifactType
+	(FlowStep_Type)(0),        // 4: lighthouse.FlowStep.Type
+	(Progress_Stage)(0),       // 5: lighthouse.Progress.Stage
+	(*Throttling)(nil),        // 6: lighthouse.Throttling
+	(*Artifact)(nil),          // 7: lighthouse.Artifact
+	(*ScreenEmulation)(nil),   // 8: lighthouse.ScreenEmulation
+	(*FlowStep)(nil),          // 9: lighthouse.FlowStep
+	(*LighthouseRequest)(nil), // 10: lighthouse.LighthouseRequest
+	(*Environment)(nil),       // 11: lighthouse.Environment
+	(*LighthouseResult)(nil),  // 12: lighthouse.LighthouseResult
+	(*Progress)(nil),          // 13: lighthouse.Progress
+	(*ResultChunk)(nil),       // 14: lighthouse.ResultChunk
+	(*RunStreamResponse)(nil), // 15: lighthouse.RunStreamResponse
+	(*StatusRequest)(nil),     // 16: lighthouse.StatusRequest
+	(*ChromePoolStatus)(nil),  // 17: lighthouse.ChromePoolStatus
+	(*StatusResponse)(nil),    // 18: lighthouse.StatusResponse
+	nil,                       // 19: lighthouse.LighthouseRequest.ExtraHeadersEntry
 }
 var file_lighthouse_proto_depIdxs = []int32{
-	0, // 0: lighthouse.LighthouseService.Run:input_type -> lighthouse.LighthouseRequest
-	1, // 1: lighthouse.LighthouseService.Run:output_type -> lighthouse.LighthouseResult
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	3,  // 0: lighthouse.Artifact.type:type_name -> lighthouse.ArtifactType
+	4,  // 1: lighthouse.FlowStep.type:type_name -> lighthouse.FlowStep.Type
+	0,  // 2: lighthouse.LighthouseRequest.result_compression:type_name -> lighthouse.Compression
+	1,  // 3: lighthouse.LighthouseRequest.form_factor:type_name -> lighthouse.FormFactor
+	2,  // 4: lighthouse.LighthouseRequest.throttling_method:type_name -> lighthouse.ThrottlingMethod
+	6,  // 5: lighthouse.LighthouseRequest.throttling:type_name -> lighthouse.Throttling
+	8,  // 6: lighthouse.LighthouseRequest.screen_emulation:type_name -> lighthouse.ScreenEmulation
+	19, // 7: lighthouse.LighthouseRequest.extra_headers:type_name -> lighthouse.LighthouseRequest.ExtraHeadersEntry
+	3,  // 8: lighthouse.LighthouseRequest.artifacts:type_name -> lighthouse.ArtifactType
+	9,  // 9: lighthouse.LighthouseRequest.flow_steps:type_name -> lighthouse.FlowStep
+	7,  // 10: lighthouse.LighthouseResult.artifacts:type_name -> lighthouse.Artifact
+	11, // 11: lighthouse.LighthouseResult.environment:type_name -> lighthouse.Environment
+	5,  // 12: lighthouse.Progress.stage:type_name -> lighthouse.Progress.Stage
+	0,  // 13: lighthouse.ResultChunk.compression:type_name -> lighthouse.Compression
+	7,  // 14: lighthouse.ResultChunk.artifact:type_name -> lighthouse.Artifact
+	13, // 15: lighthouse.RunStreamResponse.progress:type_name -> lighthouse.Progress
+	14, // 16: lighthouse.RunStreamResponse.chunk:type_name -> lighthouse.ResultChunk
+	11, // 17: lighthouse.RunStreamResponse.environment:type_name -> lighthouse.Environment
+	17, // 18: lighthouse.StatusResponse.chrome_pool:type_name -> lighthouse.ChromePoolStatus
+	10, // 19: lighthouse.LighthouseService.Run:input_type -> lighthouse.LighthouseRequest
+	16, // 20: lighthouse.LighthouseService.Status:input_type -> lighthouse.StatusRequest
+	10, // 21: lighthouse.LighthouseService.RunStream:input_type -> lighthouse.LighthouseRequest
+	12, // 22: lighthouse.LighthouseService.Run:output_type -> lighthouse.LighthouseResult
+	18, // 23: lighthouse.LighthouseService.Status:output_type -> lighthouse.StatusResponse
+	15, // 24: lighthouse.LighthouseService.RunStream:output_type -> lighthouse.RunStreamResponse
+	22, // [22:25] is the sub-list for method output_type
+	19, // [19:22] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
 }
 
 func init() { file_lighthouse_proto_init() }
@@ -194,7 +1874,7 @@ func file_lighthouse_proto_init() {
 	}
 	if !protoimpl.UnsafeEnabled {
 		file_lighthouse_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*LighthouseRequest); i {
+			switch v := v.(*Throttling); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -206,6 +1886,66 @@ func file_lighthouse_proto_init() {
 			}
 		}
 		file_lighthouse_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Artifact); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lighthouse_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ScreenEmulation); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lighthouse_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FlowStep); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lighthouse_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LighthouseRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lighthouse_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Environment); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lighthouse_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*LighthouseResult); i {
 			case 0:
 				return &v.state
@@ -217,19 +1957,97 @@ func file_lighthouse_proto_init() {
 				return nil
 			}
 		}
+		file_lighthouse_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Progress); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lighthouse_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ResultChunk); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lighthouse_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RunStreamResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lighthouse_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StatusRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lighthouse_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ChromePoolStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lighthouse_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StatusResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_lighthouse_proto_msgTypes[9].OneofWrappers = []interface{}{
+		(*RunStreamResponse_Progress)(nil),
+		(*RunStreamResponse_Chunk)(nil),
+		(*RunStreamResponse_Environment)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_lighthouse_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   2,
+			NumEnums:      6,
+			NumMessages:   14,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_lighthouse_proto_goTypes,
 		DependencyIndexes: file_lighthouse_proto_depIdxs,
+		EnumInfos:         file_lighthouse_proto_enumTypes,
 		MessageInfos:      file_lighthouse_proto_msgTypes,
 	}.Build()
 	File_lighthouse_proto = out.File
@@ -18,6 +18,11 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type LighthouseServiceClient interface {
 	Run(ctx context.Context, in *LighthouseRequest, opts ...grpc.CallOption) (*LighthouseResult, error)
+	Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error)
+	// RunStream runs lighthouse and streams progress events followed by the
+	// requested artifacts and the result split into chunks, so results aren't
+	// limited by the maximum gRPC message size.
+	RunStream(ctx context.Context, in *LighthouseRequest, opts ...grpc.CallOption) (LighthouseService_RunStreamClient, error)
 }
 
 type lighthouseServiceClient struct {
@@ -37,11 +42,57 @@ func (c *lighthouseServiceClient) Run(ctx context.Context, in *LighthouseRequest
 	return out, nil
 }
 
+func (c *lighthouseServiceClient) Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error) {
+	out := new(StatusResponse)
+	err := c.cc.Invoke(ctx, "/lighthouse.LighthouseService/Status", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lighthouseServiceClient) RunStream(ctx context.Context, in *LighthouseRequest, opts ...grpc.CallOption) (LighthouseService_RunStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_LighthouseService_serviceDesc.Streams[0], "/lighthouse.LighthouseService/RunStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &lighthouseServiceRunStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type LighthouseService_RunStreamClient interface {
+	Recv() (*RunStreamResponse, error)
+	grpc.ClientStream
+}
+
+type lighthouseServiceRunStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *lighthouseServiceRunStreamClient) Recv() (*RunStreamResponse, error) {
+	m := new(RunStreamResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // LighthouseServiceServer is the server API for LighthouseService service.
 // All implementations must embed UnimplementedLighthouseServiceServer
 // for forward compatibility
 type LighthouseServiceServer interface {
 	Run(context.Context, *LighthouseRequest) (*LighthouseResult, error)
+	Status(context.Context, *StatusRequest) (*StatusResponse, error)
+	// RunStream runs lighthouse and streams progress events followed by the
+	// requested artifacts and the result split into chunks, so results aren't
+	// limited by the maximum gRPC message size.
+	RunStream(*LighthouseRequest, LighthouseService_RunStreamServer) error
 	mustEmbedUnimplementedLighthouseServiceServer()
 }
 
@@ -52,6 +103,12 @@ type UnimplementedLighthouseServiceServer struct {
 func (UnimplementedLighthouseServiceServer) Run(context.Context, *LighthouseRequest) (*LighthouseResult, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Run not implemented")
 }
+func (UnimplementedLighthouseServiceServer) Status(context.Context, *StatusRequest) (*StatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
+}
+func (UnimplementedLighthouseServiceServer) RunStream(*LighthouseRequest, LighthouseService_RunStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method RunStream not implemented")
+}
 func (UnimplementedLighthouseServiceServer) mustEmbedUnimplementedLighthouseServiceServer() {}
 
 // UnsafeLighthouseServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -83,6 +140,45 @@ func _LighthouseService_Run_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _LighthouseService_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LighthouseServiceServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lighthouse.LighthouseService/Status",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LighthouseServiceServer).Status(ctx, req.(*StatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LighthouseService_RunStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(LighthouseRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LighthouseServiceServer).RunStream(m, &lighthouseServiceRunStreamServer{stream})
+}
+
+type LighthouseService_RunStreamServer interface {
+	Send(*RunStreamResponse) error
+	grpc.ServerStream
+}
+
+type lighthouseServiceRunStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *lighthouseServiceRunStreamServer) Send(m *RunStreamResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _LighthouseService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "lighthouse.LighthouseService",
 	HandlerType: (*LighthouseServiceServer)(nil),
@@ -91,7 +187,17 @@ var _LighthouseService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Run",
 			Handler:    _LighthouseService_Run_Handler,
 		},
+		{
+			MethodName: "Status",
+			Handler:    _LighthouseService_Status_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "RunStream",
+			Handler:       _LighthouseService_RunStream_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "lighthouse.proto",
 }
@@ -0,0 +1,83 @@
+package lighthouse
+
+import (
+	"sync"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// maxLogSize is the maximum size of the logs returned with a result.
+	// Only the end of longer logs is kept, since that's where errors are.
+	maxLogSize = 1 << 20
+	// maxErrorLogSize is the maximum size of the logs attached to errors,
+	// which are sent in the gRPC trailers.
+	maxErrorLogSize = 64 << 10
+	logsName        = "lighthouse.log"
+)
+
+// logBuffer keeps the last max bytes written to it.
+type logBuffer struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+func newLogBuffer(max int) *logBuffer {
+	return &logBuffer{max: max}
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.max {
+		b.buf = append([]byte{}, b.buf[len(b.buf)-b.max:]...)
+	}
+	return len(p), nil
+}
+
+// Tail returns the last n bytes that were written.
+func (b *logBuffer) Tail(n int) []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.buf) > n {
+		return append([]byte{}, b.buf[len(b.buf)-n:]...)
+	}
+	return append([]byte{}, b.buf...)
+}
+
+func logsArtifact(logs []byte) *Artifact {
+	return &Artifact{
+		Type:        ArtifactType_LOGS,
+		Name:        logsName,
+		ContentType: "text/plain; charset=utf-8",
+		Data:        logs,
+	}
+}
+
+// withLogs attaches the end of the logs of a failed run to the status of err
+// as DebugInfo, so clients can store why the run failed.
+func withLogs(err error, logs []byte) error {
+	if len(logs) == 0 {
+		return err
+	}
+	st := status.Convert(err)
+	detailed, derr := st.WithDetails(&errdetails.DebugInfo{Detail: string(logs)})
+	if derr != nil {
+		return err
+	}
+	return detailed.Err()
+}
+
+// ErrorLogs returns the logs that lighthouse-server attached to the status of
+// a failed run.
+func ErrorLogs(err error) string {
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.DebugInfo); ok {
+			return info.GetDetail()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+package lighthouse
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestLogBuffer(t *testing.T) {
+	b := newLogBuffer(8)
+	b.Write([]byte("0123"))
+	b.Write([]byte("456789"))
+	if got := string(b.Tail(100)); got != "23456789" {
+		t.Errorf("Expected the last 8 bytes, but got %q", got)
+	}
+	if got := string(b.Tail(3)); got != "789" {
+		t.Errorf("Expected the last 3 bytes, but got %q", got)
+	}
+}
+
+func TestWithLogs(t *testing.T) {
+	err := withLogs(errors.New("lighthouse exited"), []byte("Runtime error encountered: NO_FCP"))
+	if status.Code(err) != codes.Unknown {
+		t.Errorf("Expected code Unknown, but got %v", err)
+	}
+	if logs := ErrorLogs(err); logs != "Runtime error encountered: NO_FCP" {
+		t.Errorf("Unexpected logs %q", logs)
+	}
+	if err := withLogs(status.Error(codes.DeadlineExceeded, "timeout"), nil); ErrorLogs(err) != "" || status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("Expected the error to be unchanged without logs, but got %v", err)
+	}
+}
+
+// failingRunner writes to stderr and fails like a crashing lighthouse.
+type failingRunner struct{}
+
+func (failingRunner) Run(ctx context.Context, req RunRequest) ([]byte, error) {
+	req.Stderr.Write([]byte("LH:ChromeLauncher:error Chrome crashed\n"))
+	return nil, errors.New("exit status 1")
+}
+
+func TestRunFailureLogs(t *testing.T) {
+	s := &Server{Runner: failingRunner{}}
+	_, err := s.Run(context.Background(), &LighthouseRequest{Url: "https://www.google.com"})
+	if logs := ErrorLogs(err); !strings.Contains(logs, "Chrome crashed") {
+		t.Errorf("Expected the logs of the failed run in the error, but got %q", logs)
+	}
+}
+
+func TestLighthouseCommandVerbose(t *testing.T) {
+	command, err := lighthouseCommand(&LighthouseRequest{Url: "https://www.google.com", Verbose: true}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Contains(command, "--verbose") {
+		t.Errorf("Expected --verbose in %v", command)
+	}
+}
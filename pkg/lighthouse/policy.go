@@ -0,0 +1,148 @@
+package lighthouse
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// Policy restricts the chrome flags and lighthouse options that clients can
+// pass through the chromeflags and options fields of a LighthouseRequest.
+// Denied flags and options take precedence over allowed ones. Options are
+// rejected unless AllowRawOptions is set.
+type Policy struct {
+	// AllowRawOptions allows clients to pass lighthouse options instead of
+	// using the typed fields of the request
+	AllowRawOptions bool `json:"allow_raw_options"`
+	// AllowedChromeFlags are the names of the chrome flags clients may set,
+	// e.g. "--disable-gpu". All flags are allowed when empty.
+	AllowedChromeFlags []string `json:"allowed_chrome_flags"`
+	// DeniedChromeFlags are the names of the chrome flags clients may not set
+	DeniedChromeFlags []string `json:"denied_chrome_flags"`
+	// AllowedOptionPrefixes are the prefixes of the names of the lighthouse
+	// options clients may set, e.g. "--throttling.". All options are allowed
+	// when empty.
+	AllowedOptionPrefixes []string `json:"allowed_option_prefixes"`
+	// DeniedOptionPrefixes are the prefixes of the names of the lighthouse
+	// options clients may not set
+	DeniedOptionPrefixes []string `json:"denied_option_prefixes"`
+	// DenyConfigs rejects requests with a custom lighthouse config
+	DenyConfigs bool `json:"deny_configs"`
+	// AllowedConfigPlugins are the lighthouse plugins custom configs may use
+	AllowedConfigPlugins []string `json:"allowed_config_plugins"`
+}
+
+// DefaultPolicy returns a policy that denies the chrome flags and lighthouse
+// options that give access to the browser or the file system of the host.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		DeniedChromeFlags: []string{"--remote-debugging-address", "--remote-debugging-port",
+			"--remote-debugging-pipe", "--user-data-dir", "--disk-cache-dir", "--load-extension",
+			"--disable-extensions-except", "--enable-logging", "--log-file", "--crash-dumps-dir",
+			"--proxy-server", "--proxy-pac-url", "--host-resolver-rules", "--renderer-cmd-prefix",
+			"--gpu-launcher", "--utility-cmd-prefix", "--browser-subprocess-path"},
+		DeniedOptionPrefixes: []string{"--chrome-flags", "--chrome-ignore-default-flags", "--port",
+			"--hostname", "--output", "--output-path", "--save-assets", "--config-path", "--preset",
+			"--plugins", "--cli-flags-file-path", "--gather-mode", "--audit-mode", "-G", "-A",
+			"--precomputed-lantern-data-path", "--lantern-data-output-path", "--view", "--enable-error-reporting"},
+	}
+}
+
+// LoadPolicy reads a JSON policy file and adds its rules to the default
+// policy.
+func LoadPolicy(path string) (*Policy, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p Policy
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, fmt.Errorf("Error parsing policy file %s: %v", path, err)
+	}
+	return DefaultPolicy().Merge(&p), nil
+}
+
+// Merge adds the rules of other to p and returns p.
+func (p *Policy) Merge(other *Policy) *Policy {
+	p.AllowRawOptions = p.AllowRawOptions || other.AllowRawOptions
+	p.DenyConfigs = p.DenyConfigs || other.DenyConfigs
+	p.AllowedConfigPlugins = append(p.AllowedConfigPlugins, other.AllowedConfigPlugins...)
+	p.AllowedChromeFlags = append(p.AllowedChromeFlags, other.AllowedChromeFlags...)
+	p.DeniedChromeFlags = append(p.DeniedChromeFlags, other.DeniedChromeFlags...)
+	p.AllowedOptionPrefixes = append(p.AllowedOptionPrefixes, other.AllowedOptionPrefixes...)
+	p.DeniedOptionPrefixes = append(p.DeniedOptionPrefixes, other.DeniedOptionPrefixes...)
+	return p
+}
+
+// Check returns an error describing the first chrome flag, option or part of
+// the custom lighthouse config of the request that violates the policy.
+func (p *Policy) Check(in *LighthouseRequest) error {
+	if hasConfig(in) {
+		if p.DenyConfigs {
+			return fmt.Errorf("Custom lighthouse configs are disabled on this server")
+		}
+		config, err := customConfig(in)
+		if err != nil {
+			return err
+		}
+		if err := checkConfig(config, p.AllowedConfigPlugins); err != nil {
+			return err
+		}
+	}
+	for _, flag := range in.GetChromeflags() {
+		// Chrome flags are joined with spaces into a single lighthouse flag,
+		// so whitespace and quotes could smuggle in other flags.
+		if !strings.HasPrefix(flag, "--") || strings.ContainsAny(flag, " \t\r\n\"'") {
+			return fmt.Errorf("Chrome flag %q is malformed", flag)
+		}
+		name := flagName(flag)
+		if Contains(p.DeniedChromeFlags, name) {
+			return fmt.Errorf("Chrome flag %s is not allowed", name)
+		}
+		if len(p.AllowedChromeFlags) > 0 && !Contains(p.AllowedChromeFlags, name) {
+			return fmt.Errorf("Chrome flag %s is not in the list of allowed chrome flags", name)
+		}
+	}
+	if len(in.GetOptions()) > 0 && !p.AllowRawOptions {
+		return fmt.Errorf("Options are disabled on this server, please use the typed fields of the request")
+	}
+	for _, option := range in.GetOptions() {
+		// Everything that isn't a flag would be interpreted as another URL
+		if !strings.HasPrefix(option, "-") {
+			return fmt.Errorf("Option %q is malformed", option)
+		}
+		if hasPrefix(option, p.DeniedOptionPrefixes) {
+			return fmt.Errorf("Option %s is not allowed", flagName(option))
+		}
+		if len(p.AllowedOptionPrefixes) > 0 && !hasPrefix(option, p.AllowedOptionPrefixes) {
+			return fmt.Errorf("Option %s is not in the list of allowed options", flagName(option))
+		}
+	}
+	return nil
+}
+
+// flagName returns the name of a flag without its value.
+func flagName(flag string) string {
+	return strings.SplitN(flag, "=", 2)[0]
+}
+
+// hasPrefix returns true when the name of option starts with one of the
+// prefixes. Names are compared without dashes, underscores and case because
+// lighthouse also accepts the camelCase form of flags, e.g. --configPath.
+func hasPrefix(option string, prefixes []string) bool {
+	name := normalizeFlag(flagName(option))
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, normalizeFlag(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeFlag(flag string) string {
+	name := strings.TrimLeft(flag, "-")
+	dashes := flag[:len(flag)-len(name)]
+	name = strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(name))
+	return dashes + name
+}
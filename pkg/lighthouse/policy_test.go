@@ -0,0 +1,106 @@
+package lighthouse
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDefaultPolicy(t *testing.T) {
+	p := DefaultPolicy()
+	denied := []*LighthouseRequest{
+		{Chromeflags: []string{"--remote-debugging-address=0.0.0.0"}},
+		{Chromeflags: []string{"--user-data-dir=/root"}},
+		{Chromeflags: []string{"--lang=en --user-data-dir=/root"}},
+		{Chromeflags: []string{"-user-data-dir=/root"}},
+		{Options: []string{"--config-path=/etc/passwd"}},
+		{Options: []string{"--configPath=/etc/passwd"}},
+		{Options: []string{"--output-path=/tmp/report"}},
+		{Options: []string{"https://www.example.com"}},
+		{Options: []string{"--throttling.rttMs=150"}},
+	}
+	for _, in := range denied {
+		if err := p.Check(in); err == nil {
+			t.Errorf("Expected request with chromeflags %v and options %v to be denied",
+				in.GetChromeflags(), in.GetOptions())
+		}
+	}
+	allowed := &LighthouseRequest{Chromeflags: []string{"--disable-gpu", "--lang=en"}}
+	if err := p.Check(allowed); err != nil {
+		t.Errorf("Expected request to be allowed, but got %v", err)
+	}
+}
+
+func TestPolicyRawOptions(t *testing.T) {
+	p := DefaultPolicy().Merge(&Policy{AllowRawOptions: true})
+	in := &LighthouseRequest{Options: []string{"--throttling.rttMs=150", "--emulated-form-factor=mobile"}}
+	if err := p.Check(in); err != nil {
+		t.Errorf("Expected request to be allowed, but got %v", err)
+	}
+	if err := p.Check(&LighthouseRequest{Options: []string{"--config-path=/etc/passwd"}}); err == nil {
+		t.Error("Expected the default denied options to still apply")
+	}
+}
+
+func TestPolicyAllowlist(t *testing.T) {
+	p := DefaultPolicy().Merge(&Policy{
+		AllowRawOptions:       true,
+		AllowedChromeFlags:    []string{"--disable-gpu"},
+		AllowedOptionPrefixes: []string{"--throttling."},
+	})
+	if err := p.Check(&LighthouseRequest{Chromeflags: []string{"--lang=en"}}); err == nil {
+		t.Error("Expected --lang to be rejected by the allowlist")
+	}
+	if err := p.Check(&LighthouseRequest{Options: []string{"--locale=de"}}); err == nil {
+		t.Error("Expected --locale to be rejected by the allowlist")
+	}
+	in := &LighthouseRequest{Chromeflags: []string{"--disable-gpu"}, Options: []string{"--throttling.rttMs=0"}}
+	if err := p.Check(in); err != nil {
+		t.Errorf("Expected request to be allowed, but got %v", err)
+	}
+}
+
+func TestPolicyDenyConfigs(t *testing.T) {
+	p := DefaultPolicy().Merge(&Policy{DenyConfigs: true})
+	if err := p.Check(&LighthouseRequest{ConfigJson: `{"extends": "lighthouse:default"}`}); err == nil {
+		t.Error("Expected custom configs to be denied")
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	f, err := ioutil.TempFile("", "policy*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(`{"allow_raw_options": true, "denied_option_prefixes": ["--locale"]}`)
+	f.Close()
+	p, err := LoadPolicy(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Check(&LighthouseRequest{Options: []string{"--locale=de"}}); err == nil {
+		t.Error("Expected --locale to be denied by the policy file")
+	}
+	if err := p.Check(&LighthouseRequest{Options: []string{"--throttling.rttMs=0"}}); err != nil {
+		t.Errorf("Expected the policy file to allow raw options, but got %v", err)
+	}
+	if err := p.Check(&LighthouseRequest{Chromeflags: []string{"--user-data-dir=/"}}); err == nil {
+		t.Error("Expected the default denied chrome flags to still apply")
+	}
+}
+
+func TestRunPolicyViolation(t *testing.T) {
+	s := &Server{Runner: &FakeRunner{Dir: "testdata"}, Policy: DefaultPolicy()}
+	_, err := s.Run(context.Background(), &LighthouseRequest{
+		Url:         "https://www.google.com",
+		Chromeflags: []string{"--remote-debugging-address=0.0.0.0"},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected code InvalidArgument, but got %v", err)
+	}
+}
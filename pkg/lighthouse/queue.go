@@ -0,0 +1,106 @@
+package lighthouse
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrQueueFull is returned by RunQueue.Acquire when all run slots are taken
+// and the wait queue is full.
+var ErrQueueFull = errors.New("lighthouse run queue is full")
+
+// defaultRunDuration is used to estimate the retry delay before any run has
+// completed.
+const defaultRunDuration = 20 * time.Second
+
+// RunQueue limits the number of lighthouse runs that execute concurrently.
+// Runs that can't start right away wait in a bounded queue.
+type RunQueue struct {
+	maxConcurrent int
+	maxQueued     int
+	slots         chan struct{}
+
+	mu          sync.Mutex
+	running     int
+	queued      int
+	avgDuration time.Duration
+}
+
+// NewRunQueue creates a RunQueue that allows maxConcurrent runs at the same
+// time and up to maxQueued runs waiting for a free slot.
+func NewRunQueue(maxConcurrent int, maxQueued int) *RunQueue {
+	return &RunQueue{
+		maxConcurrent: maxConcurrent,
+		maxQueued:     maxQueued,
+		slots:         make(chan struct{}, maxConcurrent),
+	}
+}
+
+// Acquire blocks until a run slot is available or ctx is done. The returned
+// release function must be called once the run finished.
+func (q *RunQueue) Acquire(ctx context.Context) (release func(), err error) {
+	select {
+	case q.slots <- struct{}{}:
+		return q.start(), nil
+	default:
+	}
+
+	q.mu.Lock()
+	if q.queued >= q.maxQueued {
+		q.mu.Unlock()
+		return nil, ErrQueueFull
+	}
+	q.queued++
+	q.mu.Unlock()
+	defer func() {
+		q.mu.Lock()
+		q.queued--
+		q.mu.Unlock()
+	}()
+
+	select {
+	case q.slots <- struct{}{}:
+		return q.start(), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (q *RunQueue) start() func() {
+	started := time.Now()
+	q.mu.Lock()
+	q.running++
+	q.mu.Unlock()
+	return func() {
+		q.mu.Lock()
+		q.running--
+		if q.avgDuration == 0 {
+			q.avgDuration = time.Since(started)
+		} else {
+			// Exponentially weighted so that the estimate follows recent runs
+			q.avgDuration = (4*q.avgDuration + time.Since(started)) / 5
+		}
+		q.mu.Unlock()
+		<-q.slots
+	}
+}
+
+// Stats returns the number of running and queued lighthouse runs.
+func (q *RunQueue) Stats() (running int, queued int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.running, q.queued
+}
+
+// RetryDelay estimates how long it takes until the queue has room again,
+// which is roughly the duration of a single run.
+func (q *RunQueue) RetryDelay() time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.avgDuration == 0 {
+		return defaultRunDuration
+	}
+	return q.avgDuration
+}
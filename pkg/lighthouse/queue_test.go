@@ -0,0 +1,83 @@
+package lighthouse
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRunQueueFull(t *testing.T) {
+	q := NewRunQueue(1, 1)
+	release, err := q.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	acquired := make(chan func())
+	go func() {
+		r, err := q.Acquire(context.Background())
+		if err != nil {
+			t.Error(err)
+		}
+		acquired <- r
+	}()
+	// Wait for the second run to be queued
+	for {
+		if _, queued := q.Stats(); queued == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := q.Acquire(context.Background()); err != ErrQueueFull {
+		t.Errorf("Expected ErrQueueFull, but got %v", err)
+	}
+	release()
+	(<-acquired)()
+	if running, queued := q.Stats(); running != 0 || queued != 0 {
+		t.Errorf("Expected an empty queue, but got running=%v queued=%v", running, queued)
+	}
+}
+
+func TestRunQueueCanceledWhileQueued(t *testing.T) {
+	q := NewRunQueue(1, 1)
+	release, _ := q.Acquire(context.Background())
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.Acquire(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, but got %v", err)
+	}
+	if _, queued := q.Stats(); queued != 0 {
+		t.Errorf("Expected queued to be 0, but got %v", queued)
+	}
+}
+
+func TestRunResourceExhausted(t *testing.T) {
+	s := &Server{
+		Runner: &FakeRunner{Dir: "testdata", Delay: time.Minute},
+		Queue:  NewRunQueue(1, 0),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx, &LighthouseRequest{Url: "https://www.google.com"})
+	for {
+		if resp, _ := s.Status(ctx, &StatusRequest{}); resp.GetRunning() == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	_, err := s.Run(context.Background(), &LighthouseRequest{Url: "https://www.google.com"})
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("Expected code ResourceExhausted, but got %v", err)
+	}
+	if len(st.Details()) != 1 {
+		t.Fatalf("Expected a RetryInfo detail, but got %v", st.Details())
+	}
+	if ri, ok := st.Details()[0].(*errdetails.RetryInfo); !ok || ri.GetRetryDelay().AsDuration() <= 0 {
+		t.Errorf("Expected a positive retry delay, but got %v", st.Details()[0])
+	}
+}
@@ -0,0 +1,294 @@
+package lighthouse
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	neturl "net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultDockerImage = "samos123/lighthouse:9.4.0"
+	// DefaultFakeResult is the file replayed by FakeRunner when there is no
+	// result file specific to the requested host.
+	DefaultFakeResult = "default.json"
+	// maxRequestIDLength is the length of the client request ID that's used
+	// in the ID of a run
+	maxRequestIDLength = 64
+)
+
+// RunRequest describes a single lighthouse invocation.
+type RunRequest struct {
+	// ID identifies the run
+	ID string
+	// URL is the page that's being audited
+	URL string
+	// Command is the full lighthouse command line, starting with the executable
+	Command []string
+	// Stderr optionally receives the log output of lighthouse while it runs
+	Stderr io.Writer
+	// OutputDir is the directory lighthouse writes its reports and assets to.
+	// Empty when lighthouse prints the JSON result to stdout.
+	OutputDir string
+}
+
+// Runner executes lighthouse and returns the JSON result that it printed.
+// When req.OutputDir is set, lighthouse writes its results to that directory
+// instead and runners must make it available to lighthouse at the same path.
+// Implementations must stop lighthouse and return ctx.Err() once ctx is done.
+type Runner interface {
+	Run(ctx context.Context, req RunRequest) ([]byte, error)
+}
+
+// RunnerConfig holds the settings used by NewRunner to create a Runner.
+type RunnerConfig struct {
+	// LighthousePath is the lighthouse executable used by the exec runner.
+	// The lighthouse command in the PATH is used when empty.
+	LighthousePath string
+	FakeResultsDir string
+	FakeDelay      time.Duration
+	DockerImage    string
+	// DockerFlows is set when the docker images support user flows
+	DockerFlows bool
+	// DockerCPUs and DockerMemory are passed to docker run --cpus and
+	// --memory. No limit is set when empty.
+	DockerCPUs   string
+	DockerMemory string
+	// DockerNetwork is the docker network the containers are attached to
+	DockerNetwork string
+}
+
+// NewRunner returns the Runner registered under name. Possible values are
+// exec, docker and fake.
+func NewRunner(name string, config RunnerConfig) (Runner, error) {
+	switch name {
+	case "exec":
+		return &ExecRunner{Path: config.LighthousePath}, nil
+	case "docker":
+		image := config.DockerImage
+		if image == "" {
+			image = DefaultDockerImage
+		}
+		return &DockerRunner{
+			Image:   image,
+			Flows:   config.DockerFlows,
+			CPUs:    config.DockerCPUs,
+			Memory:  config.DockerMemory,
+			Network: config.DockerNetwork,
+		}, nil
+	case "fake":
+		return &FakeRunner{Dir: config.FakeResultsDir, Delay: config.FakeDelay}, nil
+	default:
+		return nil, fmt.Errorf("Unknown runner %q. Possible values are: exec, docker, fake", name)
+	}
+}
+
+// ExecRunner runs the lighthouse executable that's installed on the host.
+type ExecRunner struct {
+	// Path replaces the lighthouse executable of the command when not empty,
+	// so multiple lighthouse versions can be installed side by side.
+	Path string
+}
+
+func (r *ExecRunner) Run(ctx context.Context, req RunRequest) ([]byte, error) {
+	command := req.Command
+	if r.Path != "" && command[0] == "lighthouse" {
+		command = append([]string{r.Path}, command[1:]...)
+	}
+	return runCommand(ctx, command, req.Stderr, nil)
+}
+
+// DockerRunner runs lighthouse inside a docker container that's removed
+// after the run.
+type DockerRunner struct {
+	Image string
+	// Flows is set when the image can run user flows, which need the
+	// puppeteer-core module and NODE_PATH like the images built from
+	// build/Dockerfile_lighthouse. DefaultDockerImage can't.
+	Flows   bool
+	CPUs    string
+	Memory  string
+	Network string
+}
+
+var containerNameRegexp = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+func (r *DockerRunner) Run(ctx context.Context, req RunRequest) ([]byte, error) {
+	name := containerName(req.ID)
+	// Killing the docker client doesn't stop the container, so the container
+	// is stopped explicitly on cancellation.
+	return runCommand(ctx, r.command(name, req), req.Stderr, func() {
+		if err := exec.Command("docker", "kill", name).Run(); err != nil {
+			log.Printf("Error killing docker container %s: %v", name, err)
+		}
+	})
+}
+
+func containerName(id string) string {
+	return "websu-lighthouse-" + containerNameRegexp.ReplaceAllString(id, "_")
+}
+
+func (r *DockerRunner) command(name string, req RunRequest) []string {
+	command := []string{"docker", "run", "--rm", "--name", name}
+	if r.CPUs != "" {
+		command = append(command, "--cpus", r.CPUs)
+	}
+	if r.Memory != "" {
+		// Setting memory-swap to the same value disables swapping, which
+		// would skew the timings as much as a missing limit.
+		command = append(command, "--memory", r.Memory, "--memory-swap", r.Memory)
+	}
+	if r.Network != "" {
+		command = append(command, "--network", r.Network)
+	}
+	if req.OutputDir != "" {
+		// The directory is mounted at the same path, so the paths in the
+		// lighthouse command work unchanged inside the container.
+		command = append(command, "-v", req.OutputDir+":"+req.OutputDir)
+	}
+	command = append(command, r.Image)
+	return append(command, req.Command...)
+}
+
+// FakeRunner doesn't run lighthouse at all and instead replays canned
+// lighthouse JSON results from Dir. The result is read from <host>.json if
+// that file exists and from default.json otherwise, which makes it possible
+// to run the whole stack without Chrome.
+type FakeRunner struct {
+	Dir string
+	// Delay simulates the time lighthouse takes to run
+	Delay time.Duration
+}
+
+func (r *FakeRunner) Run(ctx context.Context, req RunRequest) ([]byte, error) {
+	// Mimic the status lines lighthouse logs while gathering and auditing
+	for _, line := range []string{"LH:status Loading page & waiting for onload", "LH:status Analyzing and running audits..."} {
+		if req.Stderr != nil {
+			fmt.Fprintln(req.Stderr, line)
+		}
+		select {
+		case <-time.After(r.Delay / 2):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	path := filepath.Join(r.Dir, DefaultFakeResult)
+	if u, err := neturl.Parse(req.URL); err == nil && u.Hostname() != "" {
+		hostPath := filepath.Join(r.Dir, u.Hostname()+".json")
+		if _, err := os.Stat(hostPath); err == nil {
+			path = hostPath
+		}
+	}
+	log.Printf("Replaying fake lighthouse result %s for %s", path, req.URL)
+	result, err := ioutil.ReadFile(path)
+	if err != nil || req.OutputDir == "" {
+		return result, err
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(req.OutputDir, flowInputFile)); err == nil {
+		var input flowInput
+		if err := json.Unmarshal(b, &input); err != nil {
+			return nil, err
+		}
+		if result, err = fakeFlowResult(&input, result); err != nil {
+			return nil, err
+		}
+	}
+	return nil, writeFakeOutput(req, result)
+}
+
+// writeFakeOutput writes the files lighthouse would write to req.OutputDir.
+func writeFakeOutput(req RunRequest, result []byte) error {
+	base := filepath.Join(req.OutputDir, reportBasename)
+	html := fmt.Sprintf("<!doctype html><html><head><title>Lighthouse Report</title></head>"+
+		"<body><script>window.__LIGHTHOUSE_JSON__ = %s;</script></body></html>", result)
+	files := map[string][]byte{
+		base + ".report.json": result,
+		base + ".report.html": []byte(html),
+	}
+	if Contains(req.Command, "--save-assets") {
+		files[base+"-0.trace.json"] = []byte(`{"traceEvents":[]}`)
+	}
+	for path, data := range files {
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runCommand runs command in its own process group and copies its stderr to
+// stderr when it's not nil. The stdout of commands that fail is returned
+// together with the error. When ctx is done before the command finishes,
+// onCancel is called and the whole process group is killed.
+func runCommand(ctx context.Context, command []string, stderr io.Writer, onCancel func()) ([]byte, error) {
+	cmd := exec.Command(command[0], command[1:]...)
+	setProcessGroup(cmd)
+	var stdOut, stdErr bytes.Buffer
+	cmd.Stdout = &stdOut
+	cmd.Stderr = &stdErr
+	if stderr != nil {
+		cmd.Stderr = io.MultiWriter(&stdErr, stderr)
+	}
+	log.Printf("Running command %v", redactCommand(command))
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		if onCancel != nil {
+			onCancel()
+		}
+		if err := killProcessGroup(cmd); err != nil {
+			log.Printf("Error killing process group of %v: %v", cmd.Process.Pid, err)
+		}
+		<-done
+		log.Printf("Killed command %v: %v", redactCommand(command), ctx.Err())
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		betterErr := fmt.Errorf("Error:%v, stderr: %s, stdout: %s", err, &stdErr, &stdOut)
+		log.Println(betterErr)
+		// Lighthouse prints its result before exiting with an error for
+		// runtime errors of the page, so the output is returned as well.
+		return stdOut.Bytes(), betterErr
+	}
+	return stdOut.Bytes(), nil
+}
+
+// redactCommand returns a copy of command for logging with the values of
+// flags that can contain credentials removed.
+func redactCommand(command []string) []string {
+	redacted := make([]string, len(command))
+	for i, arg := range command {
+		if strings.HasPrefix(arg, "--extra-headers=") {
+			arg = "--extra-headers=REDACTED"
+		}
+		redacted[i] = arg
+	}
+	return redacted
+}
+
+func randomID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
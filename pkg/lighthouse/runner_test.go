@@ -0,0 +1,56 @@
+package lighthouse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDockerRunnerCommand(t *testing.T) {
+	r, err := NewRunner("docker", RunnerConfig{
+		DockerImage:   "samos123/lighthouse:9.6.0",
+		DockerCPUs:    "1.5",
+		DockerMemory:  "2g",
+		DockerNetwork: "lighthouse",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := RunRequest{ID: "report 1/2", Command: []string{"lighthouse", "https://www.google.com"}}
+	name := containerName(req.ID)
+	if name != "websu-lighthouse-report_1_2" {
+		t.Errorf("Expected a sanitized container name, but got %s", name)
+	}
+	got := strings.Join(r.(*DockerRunner).command(name, req), " ")
+	expected := "docker run --rm --name websu-lighthouse-report_1_2 --cpus 1.5 --memory 2g --memory-swap 2g " +
+		"--network lighthouse samos123/lighthouse:9.6.0 lighthouse https://www.google.com"
+	if got != expected {
+		t.Errorf("Expected command %q, but got %q", expected, got)
+	}
+}
+
+func TestDockerRunnerDefaultImage(t *testing.T) {
+	r, _ := NewRunner("docker", RunnerConfig{})
+	got := r.(*DockerRunner).command("websu-lighthouse-1", RunRequest{})
+	if strings.Join(got, " ") != "docker run --rm --name websu-lighthouse-1 "+DefaultDockerImage {
+		t.Errorf("Expected the default image without limits, but got %v", got)
+	}
+}
+
+func TestDockerRunnerMountsOutputDir(t *testing.T) {
+	r := &DockerRunner{Image: DefaultDockerImage}
+	got := r.command("websu-lighthouse-1", RunRequest{OutputDir: "/tmp/websu-lighthouse-1"})
+	if !strings.Contains(strings.Join(got, " "), "-v /tmp/websu-lighthouse-1:/tmp/websu-lighthouse-1 "+DefaultDockerImage) {
+		t.Errorf("Expected the output dir to be mounted at the same path, but got %v", got)
+	}
+}
+
+func TestRedactCommand(t *testing.T) {
+	command := []string{"lighthouse", "https://www.google.com", `--extra-headers={"Cookie":"session=abc"}`}
+	got := strings.Join(redactCommand(command), " ")
+	if strings.Contains(got, "session=abc") || !strings.Contains(got, "--extra-headers=REDACTED") {
+		t.Errorf("Expected the extra headers to be redacted, but got %s", got)
+	}
+	if !strings.Contains(command[2], "session=abc") {
+		t.Error("Expected the original command to be unchanged")
+	}
+}
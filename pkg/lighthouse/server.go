@@ -1,82 +1,307 @@
 package lighthouse
 
 import (
-	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"log"
-	"os/exec"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 type Server struct {
 	UnimplementedLighthouseServiceServer
-	UseDocker bool
+	// Runner runs the default lighthouse version
+	Runner Runner
+	// LighthouseVersion is the lighthouse version of Runner
+	LighthouseVersion string
+	// Versions holds the runners of additional lighthouse versions that
+	// clients can request, keyed by version.
+	Versions map[string]Runner
+	// MaxRunDuration is the maximum time a single lighthouse run may take
+	// regardless of the deadline set by the client. Zero means no limit.
+	MaxRunDuration time.Duration
+	// Queue limits the number of concurrent runs. Runs aren't limited when
+	// Queue is nil.
+	Queue *RunQueue
+	// Policy restricts the chrome flags and options clients can pass. The
+	// DefaultPolicy is used when Policy is nil.
+	Policy *Policy
+	// WorkDir is where the temporary output directories of runs that return
+	// artifacts are created. The default temporary directory is used when
+	// empty.
+	WorkDir string
+	// ChromePool holds the Chrome instances lighthouse connects to. Every run
+	// starts its own Chrome when ChromePool is nil.
+	ChromePool *ChromePool
+	// Cache returns the results of identical requests without running
+	// lighthouse. Results aren't cached when Cache is nil.
+	Cache *ResultCache
+
+	// running is the number of runs that are executing
+	running int32
 }
 
 func (s *Server) Run(ctx context.Context, in *LighthouseRequest) (*LighthouseResult, error) {
 	log.Printf("Received: %v", in.GetUrl())
-	json, err := runLighthouse(in.GetUrl(), s.UseDocker, in.GetOptions(), in.GetChromeflags())
+	return s.run(ctx, in, nil)
+}
+
+func (s *Server) RunStream(in *LighthouseRequest, stream LighthouseService_RunStreamServer) error {
+	log.Printf("Received stream: %v", in.GetUrl())
+	// Progress is reported from the goroutine copying the lighthouse output,
+	// so sends on the stream are serialized.
+	var mu sync.Mutex
+	progress := func(p *Progress) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := stream.Send(&RunStreamResponse{Event: &RunStreamResponse_Progress{Progress: p}}); err != nil {
+			log.Printf("Error sending progress for %v: %v", in.GetUrl(), err)
+		}
+	}
+	result, err := s.run(stream.Context(), in, progress)
 	if err != nil {
-		return nil, err
-	} else {
-		return &LighthouseResult{Stdout: json}, nil
+		return err
 	}
+	progress(&Progress{Stage: Progress_COMPLETED})
+	mu.Lock()
+	defer mu.Unlock()
+	return sendResult(stream, result, in.GetResultCompression())
 }
 
-func runLighthouse(url string, useDocker bool, options []string, chromeflags []string) (json []byte, err error) {
-	lhCommand := []string{}
-	if useDocker {
-		lhCommand = append(lhCommand, "docker", "run", "samos123/lighthouse:9.4.0")
-	}
-	defaultChromeflags := []string{"--no-sandbox", "--headless", "--disable-dev-shm-usage",
-		"--hide-scrollbars", "--disable-features=TranslateUI", "--disable-extensions",
-		"--disable-component-extensions-with-background-pages", "--disable-background-networking", "--disable-sync",
-		"--metrics-recording-only", "--disable-default-apps", "--mute-audio", "--no-default-browser-check",
-		"--no-first-run", "--disable-backgrounding-occluded-windows", "--disable-renderer-backgrounding",
-		"--disable-background-timer-throttling", "--force-fieldtrials=*BackgroundTracing/default/",
-		"--use-gl=swiftshader", "--disable-software-rasterizer"}
-	chromeflags = append(defaultChromeflags, chromeflags...)
-	lhCommand = append(lhCommand, "lighthouse", url,
-		fmt.Sprintf("--chrome-flags=\"%s\"", strings.Join(chromeflags, " ")),
-		"--output=json", "--output-path=stdout", "--disable-dev-shm-usage",
-		"--only-categories=best-practices,performance,seo",
-		"--skip-audits=final-screenshot,screenshot-thumbnails,apple-touch-icon")
+// run waits for a free run slot and then runs lighthouse. Progress events are
+// passed to progress when it's not nil.
+func (s *Server) run(ctx context.Context, in *LighthouseRequest, progress func(*Progress)) (result *LighthouseResult, err error) {
+	policy := s.Policy
+	if policy == nil {
+		policy = DefaultPolicy()
+	}
+	if err := policy.Check(in); err != nil {
+		log.Printf("Rejected request for %v: %v", in.GetUrl(), err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	runner, err := s.runner(in.GetLighthouseVersion())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if r, ok := runner.(*ExecRunner); ok && r.Path != "" && isFlow(in) {
+		// Flows run node with the lighthouse module of NODE_PATH, so only the
+		// lighthouse executable of the version would be used.
+		return nil, status.Errorf(codes.InvalidArgument,
+			"User flows can't use lighthouse version %s on this server", in.GetLighthouseVersion())
+	}
+	if r, ok := runner.(*DockerRunner); ok && !r.Flows && isFlow(in) {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"User flows aren't supported by the docker image %s of this server", r.Image)
+	}
+	if s.Cache != nil && !in.GetNoCache() {
+		if result := s.Cache.get(in); result != nil {
+			log.Printf("Returning cached result for %v", in.GetUrl())
+			return result, nil
+		}
+	}
+	if s.ChromePool != nil && len(in.GetChromeflags()) > 0 {
+		return nil, status.Error(codes.InvalidArgument,
+			"Chrome flags aren't supported because lighthouse-server uses a pool of running Chrome instances")
+	}
+	var outputDir string
+	if needsOutputDir(in) {
+		dir, err := createOutputDir(s.WorkDir)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "Error creating output directory: %v", err)
+		}
+		defer os.RemoveAll(dir)
+		outputDir = dir
+	}
+	if hasConfig(in) && !isFlow(in) {
+		if err := writeConfig(in, outputDir); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	var command []string
+	var input *flowInput
+	if isFlow(in) {
+		if input, err = newFlowInput(in, outputDir); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	} else if command, err = lighthouseCommand(in, outputDir); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	logs := newLogBuffer(maxLogSize)
+	var stderr io.Writer = logs
+	if progress != nil {
+		stderr = io.MultiWriter(logs, newProgressWriter(progress))
+	} else {
+		progress = func(*Progress) {}
+	}
+	if s.Queue != nil {
+		progress(&Progress{Stage: Progress_QUEUED})
+		release, err := s.Queue.Acquire(ctx)
+		if err == ErrQueueFull {
+			return nil, s.queueFullError()
+		} else if err != nil {
+			return nil, runError(ctx, err)
+		}
+		defer release()
+	}
+	env := &Environment{
+		CpuCount:         int32(runtime.NumCPU()),
+		LoadAverageStart: loadAverage(),
+		ConcurrentRuns:   atomic.AddInt32(&s.running, 1),
+	}
+	defer atomic.AddInt32(&s.running, -1)
+	if s.ChromePool != nil {
+		var chrome *chromeInstance
+		if chrome, err = s.ChromePool.Acquire(ctx); err != nil {
+			return nil, runError(ctx, err)
+		}
+		// Lighthouse runs in the default browser context of the instance, so
+		// it's replaced after runs with credentials. User flows use their own
+		// incognito context.
+		discard := input == nil && len(in.GetExtraHeaders()) > 0
+		defer func() { s.ChromePool.Release(chrome, err, discard) }()
+		if input != nil {
+			input.Port = chrome.port
+		} else {
+			command = append(command, "--port="+strconv.Itoa(chrome.port))
+		}
+	}
+	if input != nil {
+		if command, err = flowCommand(input, outputDir); err != nil {
+			return nil, status.Errorf(codes.Internal, "Error writing flow input: %v", err)
+		}
+	}
+	progress(&Progress{Stage: Progress_STARTED})
+	if s.MaxRunDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.MaxRunDuration)
+		defer cancel()
+	}
+	// Clients can send the same request ID more than once, e.g. for retries,
+	// so the ID is made unique to not mix up their containers.
+	id := randomID()
+	if requestID := in.GetRequestId(); requestID != "" {
+		if len(requestID) > maxRequestIDLength {
+			requestID = requestID[:maxRequestIDLength]
+		}
+		id = requestID + "-" + id
+	}
+	json, err := s.runLighthouse(ctx, runner, RunRequest{
+		ID: id, URL: in.GetUrl(), Command: command, Stderr: stderr, OutputDir: outputDir})
+	if err != nil && ctx.Err() == nil && hasRuntimeError(json, outputDir) {
+		// The result explains why the run failed, e.g. NO_FCP, which is
+		// more useful to clients than the exit status.
+		log.Printf("Lighthouse returned a runtime error for %v", in.GetUrl())
+		err = nil
+	}
+	if err != nil {
+		return nil, withLogs(runError(ctx, err), logs.Tail(maxErrorLogSize))
+	}
+	env.LoadAverageEnd = loadAverage()
+	result = &LighthouseResult{Stdout: json, Environment: env}
+	if outputDir != "" {
+		if result.Stdout, result.Artifacts, err = readOutputDir(in, outputDir); err != nil {
+			return nil, err
+		}
+	}
+	screenshots, err := extractScreenshots(in, result.Stdout)
+	if err != nil {
+		return nil, err
+	}
+	result.Artifacts = append(result.Artifacts, screenshots...)
+	result.Artifacts = append(result.Artifacts, logsArtifact(logs.Tail(maxLogSize)))
+	addResultEnvironment(env, result.Stdout)
+	if s.Cache != nil {
+		s.Cache.add(in, result)
+	}
+	return result, nil
+}
 
-	// Update deprecated options that were in lighthouse 6.4
-	for i, option := range options {
-		if strings.HasPrefix(option, "--emulated-form-factor") {
-			formFactor := strings.Split(option, "=")[1]
-			options[i] = "--form-factor=" + formFactor
-			if formFactor == "desktop" {
-				options = append(options, "--screenEmulation.mobile=false")
-				options = append(options, "--screenEmulation.height=940")
-				options = append(options, "--screenEmulation.width=1350")
-				options = append(options, "--screenEmulation.deviceScaleFactor=1")
-				options = append(options, "--emulatedUserAgent=Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/98.0.4695.0 Safari/537.36 Chrome-Lighthouse")
-			}
-			if formFactor == "mobile" {
-				options = append(options, "--screenEmulation.mobile=true")
-				options = append(options, "--screenEmulation.height=640")
-				options = append(options, "--screenEmulation.width=360")
-				options = append(options, "--screenEmulation.deviceScaleFactor=2.625")
-				options = append(options, "--emulatedUserAgent=Mozilla/5.0 (Linux; Android 7.0; Moto G (4)) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/98.0.4695.0 Mobile Safari/537.36 Chrome-Lighthouse")
-			}
+// runner returns the runner for the requested lighthouse version.
+func (s *Server) runner(version string) (Runner, error) {
+	if version == "" || version == s.LighthouseVersion {
+		return s.Runner, nil
+	}
+	if r, ok := s.Versions[version]; ok {
+		return r, nil
+	}
+	return nil, fmt.Errorf("Lighthouse version %q is not available. Available versions: %s",
+		version, strings.Join(s.lighthouseVersions(), ", "))
+}
 
-			break
+// lighthouseVersions returns the sorted lighthouse versions clients can
+// request.
+func (s *Server) lighthouseVersions() []string {
+	versions := []string{}
+	if s.LighthouseVersion != "" {
+		versions = append(versions, s.LighthouseVersion)
+	}
+	for v := range s.Versions {
+		if v != s.LighthouseVersion {
+			versions = append(versions, v)
 		}
 	}
-	lhCommand = append(lhCommand, options...)
+	sort.Strings(versions)
+	return versions
+}
+
+func (s *Server) Status(ctx context.Context, in *StatusRequest) (*StatusResponse, error) {
+	resp := &StatusResponse{
+		LighthouseVersions:       s.lighthouseVersions(),
+		DefaultLighthouseVersion: s.LighthouseVersion,
+	}
+	if s.Queue != nil {
+		running, queued := s.Queue.Stats()
+		resp.Running = int32(running)
+		resp.Queued = int32(queued)
+		resp.MaxConcurrentRuns = int32(s.Queue.maxConcurrent)
+		resp.MaxQueuedRuns = int32(s.Queue.maxQueued)
+	}
+	if s.ChromePool != nil {
+		resp.ChromePool = s.ChromePool.Status()
+	}
+	return resp, nil
+}
+
+// queueFullError returns a RESOURCE_EXHAUSTED status with a RetryInfo detail
+// that tells the client when it's worth trying again.
+func (s *Server) queueFullError() error {
+	running, queued := s.Queue.Stats()
+	st := status.New(codes.ResourceExhausted, fmt.Sprintf(
+		"lighthouse-server is busy with %d running and %d queued runs, please retry later", running, queued))
+	detailed, err := st.WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(s.Queue.RetryDelay()),
+	})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// runError converts the error of a lighthouse run into a gRPC status error
+// so clients can tell timeouts and cancellations apart from lighthouse errors.
+func runError(ctx context.Context, err error) error {
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return status.Errorf(codes.DeadlineExceeded, "lighthouse run was stopped after exceeding its deadline: %v", err)
+	case context.Canceled:
+		return status.Errorf(codes.Canceled, "lighthouse run was canceled: %v", err)
+	}
+	return err
+}
 
-	cmd := exec.Command(lhCommand[0], lhCommand[1:]...)
-	var stdOut, stdErr bytes.Buffer
-	cmd.Stdout = &stdOut
-	cmd.Stderr = &stdErr
-	log.Printf("Running command %+v", cmd)
-	if err = cmd.Run(); err != nil {
-		betterErr := fmt.Errorf("Error:%v, stderr: %s, stdout: %s", err, &stdErr, &stdOut)
-		log.Println(betterErr)
-		return nil, betterErr
-	}
-	return stdOut.Bytes(), nil
+func (s *Server) runLighthouse(ctx context.Context, runner Runner, req RunRequest) (json []byte, err error) {
+	log.Printf("Running lighthouse for %s with request ID %s", req.URL, req.ID)
+	return runner.Run(ctx, req)
 }
@@ -1,9 +1,15 @@
 package lighthouse
 
 import (
+	"context"
+	"errors"
 	"os/exec"
-	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func commandExists(cmd string) bool {
@@ -12,25 +18,29 @@ func commandExists(cmd string) bool {
 }
 
 func TestRunLighthouse(t *testing.T) {
-	if !commandExists("lighthouse") && !commandExists("docker") {
-		t.Skip("lighthouse executable not available so skipping the test")
-		return
-	}
-	tests := []bool{}
+	runners := []string{"fake"}
 	if commandExists("lighthouse") {
-		tests = append(tests, false)
+		runners = append(runners, "exec")
 	}
 	if commandExists("docker") {
-		tests = append(tests, true)
+		runners = append(runners, "docker")
 	}
 
-	for _, useDocker := range tests {
-		useDocker := useDocker // capture range variable
-		t.Run("TestRunLighthouse-useDocker-"+strconv.FormatBool(useDocker), func(t *testing.T) {
+	for _, name := range runners {
+		name := name // capture range variable
+		t.Run("TestRunLighthouse-runner-"+name, func(t *testing.T) {
 			t.Parallel()
-			options := []string{}
-			chromeflags := []string{}
-			jsonResult, err := runLighthouse("https://www.google.com", useDocker, options, chromeflags)
+			runner, err := NewRunner(name, RunnerConfig{FakeResultsDir: "testdata"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := &Server{Runner: runner}
+			command, err := lighthouseCommand(&LighthouseRequest{Url: "https://www.google.com"}, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := RunRequest{ID: "test-" + name, URL: "https://www.google.com", Command: command}
+			jsonResult, err := s.runLighthouse(context.Background(), runner, req)
 			if err != nil {
 				t.Errorf("Error running lighthouse: %v\n", err)
 			}
@@ -42,3 +52,144 @@ func TestRunLighthouse(t *testing.T) {
 	}
 
 }
+
+func TestNewRunnerUnknown(t *testing.T) {
+	if _, err := NewRunner("chrome", RunnerConfig{}); err == nil {
+		t.Error("Expected an error for an unknown runner")
+	}
+}
+
+func TestFakeRunnerMissingDir(t *testing.T) {
+	r := &FakeRunner{Dir: "doesnotexist"}
+	if _, err := r.Run(context.Background(), RunRequest{URL: "https://www.google.com"}); err == nil {
+		t.Error("Expected an error when the fake results dir doesn't exist")
+	}
+}
+
+func TestRunMaxRunDuration(t *testing.T) {
+	s := &Server{
+		Runner:         &FakeRunner{Dir: "testdata", Delay: time.Minute},
+		MaxRunDuration: 50 * time.Millisecond,
+	}
+	_, err := s.Run(context.Background(), &LighthouseRequest{Url: "https://www.google.com"})
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("Expected code DeadlineExceeded, but got %v", err)
+	}
+}
+
+func TestRunCanceled(t *testing.T) {
+	s := &Server{Runner: &FakeRunner{Dir: "testdata", Delay: time.Minute}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := s.Run(ctx, &LighthouseRequest{Url: "https://www.google.com"})
+	if status.Code(err) != codes.Canceled {
+		t.Errorf("Expected code Canceled, but got %v", err)
+	}
+}
+
+func TestRunCommandKillsProcessGroup(t *testing.T) {
+	if !commandExists("sh") || !commandExists("sleep") {
+		t.Skip("sh or sleep not available so skipping the test")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	// The background sleep keeps stdout open, so runCommand only returns
+	// early when the child processes are killed as well.
+	_, err := runCommand(ctx, []string{"sh", "-c", "sleep 30 & sleep 30"}, nil, nil)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, but got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Expected the command to be killed right away, but it took %v", elapsed)
+	}
+}
+
+func TestRunLighthouseVersion(t *testing.T) {
+	s := &Server{
+		Runner:            &FakeRunner{Dir: "doesnotexist"},
+		LighthouseVersion: "9.4.0",
+		Versions:          map[string]Runner{"9.6.0": &FakeRunner{Dir: "testdata"}},
+	}
+	if _, err := s.Run(context.Background(), &LighthouseRequest{Url: "https://www.google.com", LighthouseVersion: "9.6.0"}); err != nil {
+		t.Errorf("Expected the runner of version 9.6.0 to be used, but got %v", err)
+	}
+	_, err := s.Run(context.Background(), &LighthouseRequest{Url: "https://www.google.com", LighthouseVersion: "7.0.0"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected code InvalidArgument for an unknown version, but got %v", err)
+	}
+	resp, err := s.Status(context.Background(), &StatusRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions := resp.GetLighthouseVersions()
+	if len(versions) != 2 || versions[0] != "9.4.0" || versions[1] != "9.6.0" || resp.GetDefaultLighthouseVersion() != "9.4.0" {
+		t.Errorf("Unexpected versions %v with default %s", versions, resp.GetDefaultLighthouseVersion())
+	}
+}
+
+// runtimeErrorRunner fails like lighthouse does after saving a result with a
+// runtime error.
+type runtimeErrorRunner struct{}
+
+func (runtimeErrorRunner) Run(ctx context.Context, req RunRequest) ([]byte, error) {
+	return []byte(`{"runtimeError": {"code": "NO_FCP", "message": "The page did not paint any content."}}`),
+		errors.New("exit status 1")
+}
+
+func TestRunRuntimeError(t *testing.T) {
+	s := &Server{Runner: runtimeErrorRunner{}}
+	result, err := s.Run(context.Background(), &LighthouseRequest{Url: "https://www.google.com"})
+	if err != nil {
+		t.Fatalf("Expected the result with the runtime error, but got %v", err)
+	}
+	if !strings.Contains(string(result.GetStdout()), "NO_FCP") {
+		t.Errorf("Unexpected result %s", result.GetStdout())
+	}
+	if _, err := s.Run(context.Background(), &LighthouseRequest{Url: "https://www.google.com",
+		Artifacts: []ArtifactType{ArtifactType_HTML_REPORT}}); err == nil {
+		t.Error("Expected an error when lighthouse didn't save a result")
+	}
+}
+
+func TestRunRequestID(t *testing.T) {
+	runner := &commandRunner{FakeRunner: FakeRunner{Dir: "testdata"}}
+	s := &Server{Runner: runner}
+	in := &LighthouseRequest{Url: "https://www.google.com", RequestId: "report-1"}
+	for i := 0; i < 2; i++ {
+		if _, err := s.Run(context.Background(), in); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(runner.ids) != 2 || runner.ids[0] == runner.ids[1] ||
+		!strings.HasPrefix(runner.ids[0], "report-1-") || !strings.HasPrefix(runner.ids[1], "report-1-") {
+		t.Errorf("Expected unique IDs starting with the request ID, but got %v", runner.ids)
+	}
+}
+
+func TestRunFlowLighthouseVersion(t *testing.T) {
+	s := &Server{
+		Runner:            &FakeRunner{Dir: "testdata"},
+		LighthouseVersion: "9.4.0",
+		Versions:          map[string]Runner{"9.6.0": &ExecRunner{Path: "/opt/lighthouse-9.6.0/bin/lighthouse"}},
+	}
+	_, err := s.Run(context.Background(), &LighthouseRequest{
+		Url:               "https://www.google.com",
+		LighthouseVersion: "9.6.0",
+		FlowSteps:         []*FlowStep{{Type: FlowStep_SNAPSHOT}},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected code InvalidArgument for a flow with the version of an exec runner, but got %v", err)
+	}
+}
+
+func TestRunFlowDockerImage(t *testing.T) {
+	s := &Server{Runner: &DockerRunner{Image: DefaultDockerImage}}
+	_, err := s.Run(context.Background(), &LighthouseRequest{
+		Url:       "https://www.google.com",
+		FlowSteps: []*FlowStep{{Type: FlowStep_SNAPSHOT}},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected code InvalidArgument for a flow with a docker image without flow support, but got %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package lighthouse
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+)
+
+// ChunkSize is the maximum size of the result data sent in a single
+// RunStreamResponse, well below the default gRPC message size limit of 4MB.
+const ChunkSize = 1 << 20
+
+// progressWriter parses the status lines that lighthouse logs to stderr and
+// reports a Progress event whenever lighthouse enters a new stage.
+type progressWriter struct {
+	report func(*Progress)
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	stage  Progress_Stage
+}
+
+func newProgressWriter(report func(*Progress)) *progressWriter {
+	return &progressWriter{report: report, stage: Progress_STARTED}
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf.Write(p)
+	for {
+		line, err := w.buf.ReadString('\n')
+		if err != nil {
+			// Keep the incomplete line until the rest of it is written
+			w.buf.WriteString(line)
+			return len(p), nil
+		}
+		w.parseLine(strings.TrimSpace(line))
+	}
+}
+
+func (w *progressWriter) parseLine(line string) {
+	i := strings.Index(line, "status ")
+	if i < 0 {
+		return
+	}
+	message := strings.TrimSpace(line[i+len("status "):])
+	stage := w.stage
+	switch {
+	case strings.HasPrefix(message, "Loading page"), strings.HasPrefix(message, "Gathering"):
+		stage = Progress_GATHERING
+	case strings.HasPrefix(message, "Analyzing and running audits"), strings.HasPrefix(message, "Auditing"):
+		stage = Progress_AUDITING
+	}
+	if stage != w.stage {
+		w.stage = stage
+		w.report(&Progress{Stage: stage, Message: message})
+	}
+}
+
+// sendResult sends the environment and the artifacts of result followed by
+// the lighthouse JSON on stream. The artifacts and the JSON are split into
+// chunks and compressed first when requested.
+func sendResult(stream LighthouseService_RunStreamServer, result *LighthouseResult, compression Compression) error {
+	if env := result.GetEnvironment(); env != nil {
+		if err := stream.Send(&RunStreamResponse{Event: &RunStreamResponse_Environment{Environment: env}}); err != nil {
+			return err
+		}
+	}
+	for _, a := range result.GetArtifacts() {
+		meta := &Artifact{Type: a.Type, Name: a.Name, ContentType: a.ContentType, Timing: a.Timing}
+		if err := sendChunks(stream, a.GetData(), compression, meta); err != nil {
+			return err
+		}
+	}
+	return sendChunks(stream, result.GetStdout(), compression, nil)
+}
+
+// sendChunks splits data into chunks and sends them on stream. The chunks
+// belong to artifact when it's not nil and to the lighthouse JSON otherwise.
+func sendChunks(stream LighthouseService_RunStreamServer, data []byte, compression Compression, artifact *Artifact) error {
+	if compression == Compression_GZIP {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	}
+	total := int64(len(data))
+	for {
+		n := len(data)
+		if n > ChunkSize {
+			n = ChunkSize
+		}
+		chunk := &ResultChunk{
+			Data:        data[:n],
+			Compression: compression,
+			TotalSize:   total,
+			Last:        n == len(data),
+			Artifact:    artifact,
+		}
+		if err := stream.Send(&RunStreamResponse{Event: &RunStreamResponse_Chunk{Chunk: chunk}}); err != nil {
+			return err
+		}
+		if chunk.Last {
+			return nil
+		}
+		data = data[n:]
+	}
+}
+
+// ReceiveResult reads a RunStream response stream until the last chunk of
+// the lighthouse JSON and returns the reassembled result including its
+// artifacts and environment. Progress events are passed to progress when
+// it's not nil.
+func ReceiveResult(stream LighthouseService_RunStreamClient, progress func(*Progress)) (*LighthouseResult, error) {
+	result := &LighthouseResult{}
+	var data bytes.Buffer
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return nil, fmt.Errorf("RunStream ended before the last result chunk was received")
+		} else if err != nil {
+			return nil, err
+		}
+		if p := resp.GetProgress(); p != nil {
+			if progress != nil {
+				progress(p)
+			}
+			continue
+		}
+		if env := resp.GetEnvironment(); env != nil {
+			result.Environment = env
+			continue
+		}
+		chunk := resp.GetChunk()
+		if chunk == nil {
+			continue
+		}
+		data.Write(chunk.GetData())
+		if !chunk.GetLast() {
+			continue
+		}
+		b, err := chunkData(&data, chunk)
+		if err != nil {
+			return nil, err
+		}
+		data.Reset()
+		if a := chunk.GetArtifact(); a != nil {
+			result.Artifacts = append(result.Artifacts, &Artifact{
+				Type: a.Type, Name: a.Name, ContentType: a.ContentType, Timing: a.Timing, Data: b})
+			continue
+		}
+		result.Stdout = b
+		return result, nil
+	}
+}
+
+// chunkData checks and decompresses the data reassembled from chunks, given
+// the last chunk.
+func chunkData(data *bytes.Buffer, last *ResultChunk) ([]byte, error) {
+	if int64(data.Len()) != last.GetTotalSize() {
+		return nil, fmt.Errorf("Received %d bytes of result data, but expected %d bytes",
+			data.Len(), last.GetTotalSize())
+	}
+	if last.GetCompression() == Compression_GZIP {
+		zr, err := gzip.NewReader(data)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return ioutil.ReadAll(zr)
+	}
+	return append([]byte{}, data.Bytes()...), nil
+}
@@ -0,0 +1,129 @@
+package lighthouse
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func startTestServer(t *testing.T, s *Server) LighthouseServiceClient {
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	RegisterLighthouseServiceServer(gs, s)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(),
+		grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+			return lis.Dial()
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewLighthouseServiceClient(conn)
+}
+
+func TestRunStreamLargeResult(t *testing.T) {
+	dir, err := ioutil.TempDir("", "websu-fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// Bigger than the default gRPC message size limit of 4MB
+	result := append([]byte(`{"padding": "`), bytes.Repeat([]byte("x"), 5<<20)...)
+	result = append(result, []byte(`"}`)...)
+	if err := ioutil.WriteFile(filepath.Join(dir, DefaultFakeResult), result, 0644); err != nil {
+		t.Fatal(err)
+	}
+	client := startTestServer(t, &Server{Runner: &FakeRunner{Dir: dir}, Queue: NewRunQueue(1, 1)})
+
+	for _, compression := range []Compression{Compression_NONE, Compression_GZIP} {
+		stream, err := client.RunStream(context.Background(), &LighthouseRequest{
+			Url:               "https://www.google.com",
+			ResultCompression: compression,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var stages []Progress_Stage
+		got, err := ReceiveResult(stream, func(p *Progress) {
+			stages = append(stages, p.GetStage())
+		})
+		if err != nil {
+			t.Fatalf("Error receiving result with compression %v: %v", compression, err)
+		}
+		if !bytes.Equal(got.GetStdout(), result) {
+			t.Errorf("Expected a result of %d bytes, but got %d bytes", len(result), len(got.GetStdout()))
+		}
+		expected := []Progress_Stage{Progress_QUEUED, Progress_STARTED, Progress_GATHERING,
+			Progress_AUDITING, Progress_COMPLETED}
+		if len(stages) != len(expected) {
+			t.Fatalf("Expected stages %v, but got %v", expected, stages)
+		}
+		for i := range expected {
+			if stages[i] != expected[i] {
+				t.Errorf("Expected stages %v, but got %v", expected, stages)
+			}
+		}
+	}
+}
+
+func TestRunStreamArtifacts(t *testing.T) {
+	client := startTestServer(t, &Server{Runner: &FakeRunner{Dir: "testdata"}})
+	stream, err := client.RunStream(context.Background(), &LighthouseRequest{
+		Url:               "https://www.google.com",
+		ResultCompression: Compression_GZIP,
+		Artifacts:         []ArtifactType{ArtifactType_HTML_REPORT, ArtifactType_TRACE},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReceiveResult(stream, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := ioutil.ReadFile(filepath.Join("testdata", DefaultFakeResult))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.GetStdout(), expected) {
+		t.Errorf("Expected the JSON result to be received after the artifacts")
+	}
+	if len(got.GetArtifacts()) != 3 {
+		t.Fatalf("Expected 3 artifacts, but got %d", len(got.GetArtifacts()))
+	}
+	html := got.GetArtifacts()[0]
+	if html.GetType() != ArtifactType_HTML_REPORT || !bytes.HasPrefix(html.GetData(), []byte("<!doctype html>")) {
+		t.Errorf("Expected the HTML report as first artifact, but got %v %q", html.GetType(), html.GetName())
+	}
+	if trace := got.GetArtifacts()[1]; trace.GetType() != ArtifactType_TRACE || trace.GetName() != "0.trace.json" {
+		t.Errorf("Expected the trace as second artifact, but got %v %q", trace.GetType(), trace.GetName())
+	}
+	if logs := got.GetArtifacts()[2]; logs.GetType() != ArtifactType_LOGS || !bytes.Contains(logs.GetData(), []byte("LH:status")) {
+		t.Errorf("Expected the logs as third artifact, but got %v %q", logs.GetType(), logs.GetData())
+	}
+}
+
+func TestProgressWriterPartialLines(t *testing.T) {
+	var got []*Progress
+	w := newProgressWriter(func(p *Progress) { got = append(got, p) })
+	w.Write([]byte("  LH:status Connecting to browser +0ms\n  LH:status Gather"))
+	w.Write([]byte("ing in-page: LinkElements +1s\n  LH:status Gathering trace +2s\n"))
+	w.Write([]byte("  LH:status Auditing: Uses HTTPS +3s\n"))
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 progress events, but got %v", got)
+	}
+	if got[0].GetStage() != Progress_GATHERING || got[0].GetMessage() != "Gathering in-page: LinkElements +1s" {
+		t.Errorf("Unexpected first progress event %v", got[0])
+	}
+	if got[1].GetStage() != Progress_AUDITING {
+		t.Errorf("Expected the second progress event to be AUDITING, but got %v", got[1])
+	}
+}
@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/websu-io/websu/pkg/lighthouse (interfaces: LighthouseServiceClient)
+// Source: github.com/websu-io/websu/pkg/lighthouse (interfaces: LighthouseServiceClient,LighthouseService_RunStreamClient)
 
 // Package mocks is a generated GoMock package.
 package mocks
@@ -9,6 +9,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	lighthouse "github.com/websu-io/websu/pkg/lighthouse"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
 	reflect "reflect"
 )
 
@@ -54,3 +55,166 @@ func (mr *MockLighthouseServiceClientMockRecorder) Run(arg0, arg1 interface{}, a
 	varargs := append([]interface{}{arg0, arg1}, arg2...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockLighthouseServiceClient)(nil).Run), varargs...)
 }
+
+// RunStream mocks base method
+func (m *MockLighthouseServiceClient) RunStream(arg0 context.Context, arg1 *lighthouse.LighthouseRequest, arg2 ...grpc.CallOption) (lighthouse.LighthouseService_RunStreamClient, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RunStream", varargs...)
+	ret0, _ := ret[0].(lighthouse.LighthouseService_RunStreamClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunStream indicates an expected call of RunStream
+func (mr *MockLighthouseServiceClientMockRecorder) RunStream(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunStream", reflect.TypeOf((*MockLighthouseServiceClient)(nil).RunStream), varargs...)
+}
+
+// Status mocks base method
+func (m *MockLighthouseServiceClient) Status(arg0 context.Context, arg1 *lighthouse.StatusRequest, arg2 ...grpc.CallOption) (*lighthouse.StatusResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Status", varargs...)
+	ret0, _ := ret[0].(*lighthouse.StatusResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Status indicates an expected call of Status
+func (mr *MockLighthouseServiceClientMockRecorder) Status(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Status", reflect.TypeOf((*MockLighthouseServiceClient)(nil).Status), varargs...)
+}
+
+// MockLighthouseService_RunStreamClient is a mock of LighthouseService_RunStreamClient interface
+type MockLighthouseService_RunStreamClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockLighthouseService_RunStreamClientMockRecorder
+}
+
+// MockLighthouseService_RunStreamClientMockRecorder is the mock recorder for MockLighthouseService_RunStreamClient
+type MockLighthouseService_RunStreamClientMockRecorder struct {
+	mock *MockLighthouseService_RunStreamClient
+}
+
+// NewMockLighthouseService_RunStreamClient creates a new mock instance
+func NewMockLighthouseService_RunStreamClient(ctrl *gomock.Controller) *MockLighthouseService_RunStreamClient {
+	mock := &MockLighthouseService_RunStreamClient{ctrl: ctrl}
+	mock.recorder = &MockLighthouseService_RunStreamClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockLighthouseService_RunStreamClient) EXPECT() *MockLighthouseService_RunStreamClientMockRecorder {
+	return m.recorder
+}
+
+// CloseSend mocks base method
+func (m *MockLighthouseService_RunStreamClient) CloseSend() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseSend")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseSend indicates an expected call of CloseSend
+func (mr *MockLighthouseService_RunStreamClientMockRecorder) CloseSend() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseSend", reflect.TypeOf((*MockLighthouseService_RunStreamClient)(nil).CloseSend))
+}
+
+// Context mocks base method
+func (m *MockLighthouseService_RunStreamClient) Context() context.Context {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Context")
+	ret0, _ := ret[0].(context.Context)
+	return ret0
+}
+
+// Context indicates an expected call of Context
+func (mr *MockLighthouseService_RunStreamClientMockRecorder) Context() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockLighthouseService_RunStreamClient)(nil).Context))
+}
+
+// Header mocks base method
+func (m *MockLighthouseService_RunStreamClient) Header() (metadata.MD, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Header")
+	ret0, _ := ret[0].(metadata.MD)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Header indicates an expected call of Header
+func (mr *MockLighthouseService_RunStreamClientMockRecorder) Header() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Header", reflect.TypeOf((*MockLighthouseService_RunStreamClient)(nil).Header))
+}
+
+// Recv mocks base method
+func (m *MockLighthouseService_RunStreamClient) Recv() (*lighthouse.RunStreamResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Recv")
+	ret0, _ := ret[0].(*lighthouse.RunStreamResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Recv indicates an expected call of Recv
+func (mr *MockLighthouseService_RunStreamClientMockRecorder) Recv() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recv", reflect.TypeOf((*MockLighthouseService_RunStreamClient)(nil).Recv))
+}
+
+// RecvMsg mocks base method
+func (m *MockLighthouseService_RunStreamClient) RecvMsg(arg0 interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecvMsg", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecvMsg indicates an expected call of RecvMsg
+func (mr *MockLighthouseService_RunStreamClientMockRecorder) RecvMsg(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecvMsg", reflect.TypeOf((*MockLighthouseService_RunStreamClient)(nil).RecvMsg), arg0)
+}
+
+// SendMsg mocks base method
+func (m *MockLighthouseService_RunStreamClient) SendMsg(arg0 interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendMsg", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMsg indicates an expected call of SendMsg
+func (mr *MockLighthouseService_RunStreamClientMockRecorder) SendMsg(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMsg", reflect.TypeOf((*MockLighthouseService_RunStreamClient)(nil).SendMsg), arg0)
+}
+
+// Trailer mocks base method
+func (m *MockLighthouseService_RunStreamClient) Trailer() metadata.MD {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Trailer")
+	ret0, _ := ret[0].(metadata.MD)
+	return ret0
+}
+
+// Trailer indicates an expected call of Trailer
+func (mr *MockLighthouseService_RunStreamClientMockRecorder) Trailer() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trailer", reflect.TypeOf((*MockLighthouseService_RunStreamClient)(nil).Trailer))
+}